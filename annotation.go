@@ -0,0 +1,93 @@
+package jsonschema
+
+import "encoding/json"
+
+// Annotation is a value of annotation keyword, like "title" or "default",
+// collected during validation.
+type Annotation struct {
+	// InstanceLocation is a JSON Pointer to the annotated value.
+	InstanceLocation string
+	// KeywordLocation is a JSON Pointer to the keyword, relative to the
+	// root schema.
+	KeywordLocation string
+	// AbsoluteKeywordLocation is an absolute URI of the keyword.
+	AbsoluteKeywordLocation string
+	// Keyword is the name of the keyword.
+	Keyword string
+	// Value is a JSON value of the keyword.
+	Value json.RawMessage
+}
+
+// Annotations maps instance locations to collected annotations.
+//
+// Annotations of each location are in evaluation order.
+type Annotations map[string][]Annotation
+
+// Get returns value of the first annotation of given keyword at given
+// instance location.
+func (a Annotations) Get(loc, keyword string) (json.RawMessage, bool) {
+	for _, an := range a[loc] {
+		if an.Keyword == keyword {
+			return an.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Annotate validates given data and collects annotations of the
+// successfully validated subschemas.
+//
+// Collected keywords are "title", "description", "default", "examples",
+// "deprecated", "readOnly" and "writeOnly". Annotations of the failed
+// subschemas, like failed "anyOf" branches, are dropped.
+//
+// If data is not valid, returned error is ValidationErrors.
+func (s *Schema) Annotate(data []byte) (Annotations, error) {
+	var list []Annotation
+	if err := s.validateAll(&validator{all: true, annotations: &list}, data); err != nil {
+		return nil, err
+	}
+
+	r := Annotations{}
+	for _, an := range list {
+		r[an.InstanceLocation] = append(r[an.InstanceLocation], an)
+	}
+	return r, nil
+}
+
+// annotate adds annotations of schema s to the list.
+func (s *Schema) annotate(v *validator) {
+	add := func(keyword string, value json.RawMessage) {
+		*v.annotations = append(*v.annotations, Annotation{
+			InstanceLocation:        v.instance.String(),
+			KeywordLocation:         v.keyword.String() + "/" + keyword,
+			AbsoluteKeywordLocation: s.loc + "/" + keyword,
+			Keyword:                 keyword,
+			Value:                   value,
+		})
+	}
+	if s.title != "" {
+		value, _ := json.Marshal(s.title)
+		add("title", value)
+	}
+	if s.description != "" {
+		value, _ := json.Marshal(s.description)
+		add("description", value)
+	}
+	if s.defaultValue != nil {
+		add("default", s.defaultValue)
+	}
+	if s.examples != nil {
+		value, _ := json.Marshal(s.examples)
+		add("examples", value)
+	}
+	if s.deprecated {
+		add("deprecated", json.RawMessage("true"))
+	}
+	if s.readOnly {
+		add("readOnly", json.RawMessage("true"))
+	}
+	if s.writeOnly {
+		add("writeOnly", json.RawMessage("true"))
+	}
+}
@@ -0,0 +1,135 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_Annotate(t *testing.T) {
+	a := require.New(t)
+
+	s, err := ParseWithOptions([]byte(`{
+	"title": "Server",
+	"type": "object",
+	"properties": {
+		"host": {
+			"description": "Host name",
+			"default": "localhost",
+			"examples": ["example.com"]
+		},
+		"port": {"$ref": "#/definitions/port"},
+		"token": {
+			"anyOf": [
+				{"title": "Number token", "type": "number"},
+				{"title": "String token", "type": "string", "writeOnly": true}
+			]
+		},
+		"tags": {
+			"items": {"title": "Tag"}
+		},
+		"id": {"readOnly": true, "default": null}
+	},
+	"definitions": {
+		"port": {"title": "Port", "default": 8080, "type": "integer"}
+	}
+}`), Options{Draft: Draft7})
+	a.NoError(err)
+
+	annotations, err := s.Annotate([]byte(`{
+	"host": "example.com",
+	"port": 80,
+	"token": "secret",
+	"tags": ["a", "b"],
+	"id": 1
+}`))
+	a.NoError(err)
+
+	get := func(loc, keyword string) string {
+		value, ok := annotations.Get(loc, keyword)
+		if !ok {
+			return ""
+		}
+		return string(value)
+	}
+	a.Equal(`"Server"`, get("", "title"))
+	a.Equal(`"Host name"`, get("/host", "description"))
+	a.Equal(`"localhost"`, get("/host", "default"))
+	a.Equal(`["example.com"]`, get("/host", "examples"))
+	a.Equal(`"Port"`, get("/port", "title"))
+	a.Equal(`8080`, get("/port", "default"))
+	// Annotations of failed "anyOf" branch are dropped.
+	a.Equal(`"String token"`, get("/token", "title"))
+	a.Len(annotations["/token"], 2)
+	a.Equal(`true`, get("/token", "writeOnly"))
+	a.Equal(`"Tag"`, get("/tags/0", "title"))
+	a.Equal(`"Tag"`, get("/tags/1", "title"))
+	a.Equal(`true`, get("/id", "readOnly"))
+	a.Equal(`null`, get("/id", "default"))
+
+	a.Equal(Annotation{
+		InstanceLocation:        "/port",
+		KeywordLocation:         "/properties/port/$ref/title",
+		AbsoluteKeywordLocation: "#/definitions/port/title",
+		Keyword:                 "title",
+		Value:                   json.RawMessage(`"Port"`),
+	}, annotations["/port"][0])
+
+	_, err = s.Annotate([]byte(`{"port": "80"}`))
+	_, ok := asValidationErrors(err)
+	a.True(ok, "unexpected error %v", err)
+}
+
+func TestSchema_AnnotateAnyOf(t *testing.T) {
+	a := require.New(t)
+
+	s, err := Parse([]byte(`{"anyOf": [{"title": "a"}, {"title": "b"}, {"title": "c", "type": "string"}]}`))
+	a.NoError(err)
+
+	annotations, err := s.Annotate([]byte(`1`))
+	a.NoError(err)
+
+	// Annotations of every matching branch are collected.
+	var locations []string
+	for _, annotation := range annotations[""] {
+		locations = append(locations, annotation.KeywordLocation)
+	}
+	a.Equal([]string{"/anyOf/0/title", "/anyOf/1/title"}, locations)
+}
+
+func TestRawSchema_Annotations(t *testing.T) {
+	a := require.New(t)
+
+	const input = `{
+	"$defs": {"foo": {"title": "Foo"}},
+	"definitions": {"bar": {"type": "string"}, "baz": {"type": "number"}},
+	"title": "Title",
+	"description": "Description",
+	"default": {"foo": 1},
+	"examples": [1, "2"],
+	"deprecated": true
+}`
+	var raw RawSchema
+	a.NoError(json.Unmarshal([]byte(input), &raw))
+	a.Equal("Title", raw.Title)
+	a.Equal("Description", raw.Description)
+	a.JSONEq(`{"foo": 1}`, string(raw.Default))
+	a.Len(raw.Examples, 2)
+	a.True(raw.Deprecated)
+	a.Equal("Foo", raw.Defs[0].Schema.Title)
+	a.Equal("bar", raw.Definitions[0].Name)
+	a.Equal("baz", raw.Definitions[1].Name)
+
+	data, err := json.Marshal(raw)
+	a.NoError(err)
+	var decoded RawSchema
+	a.NoError(json.Unmarshal(data, &decoded))
+	a.Equal(raw.Title, decoded.Title)
+	a.Equal(raw.Description, decoded.Description)
+	a.JSONEq(string(raw.Default), string(decoded.Default))
+	a.Len(decoded.Examples, 2)
+	a.True(decoded.Deprecated)
+	a.Len(decoded.Defs, 1)
+	a.Len(decoded.Definitions, 2)
+}
@@ -0,0 +1,111 @@
+package jsonschema
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/go-faster/errors"
+)
+
+// catalog is a schema catalog in SchemaStore format.
+//
+// See https://json.schemastore.org/schema-catalog.json.
+type catalog struct {
+	Schemas []catalogEntry `json:"schemas"`
+}
+
+type catalogEntry struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Versions maps version names to schema URLs.
+	Versions map[string]string `json:"versions"`
+}
+
+var _ RemoteResolver = (*CatalogResolver)(nil)
+
+// CatalogResolver is RemoteResolver, that loads schemas listed in
+// a SchemaStore catalog (catalog.json) from a file system.
+type CatalogResolver struct {
+	fsys fs.FS
+	// files maps normalized schema URLs to file names.
+	files map[string]string
+}
+
+// NewCatalogResolver parses given catalog and creates CatalogResolver.
+//
+// Schema is loaded from fsys by the host and path of its URL, e.g.
+// "https://json.schemastore.org/golangci-lint.json" is loaded from
+// "json.schemastore.org/golangci-lint.json". Relative URLs are used as
+// paths in fsys.
+//
+// "http" and "https" URLs of the same schema are considered equal.
+func NewCatalogResolver(data []byte, fsys fs.FS) (*CatalogResolver, error) {
+	var c catalog
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errors.Wrap(err, "parse catalog")
+	}
+
+	r := &CatalogResolver{
+		fsys:  fsys,
+		files: map[string]string{},
+	}
+	add := func(name, loc string) error {
+		if loc == "" {
+			return nil
+		}
+		u, err := url.Parse(loc)
+		if err != nil {
+			return errors.Wrapf(err, "schema %q: parse URL", name)
+		}
+
+		file := u.Path
+		if u.IsAbs() {
+			file = strings.ToLower(u.Host) + u.Path
+		}
+		if !fs.ValidPath(file) || file == "." {
+			return errors.Errorf("schema %q: invalid file name %q", name, file)
+		}
+		r.files[catalogKey(u)] = file
+		return nil
+	}
+	for _, e := range c.Schemas {
+		if err := add(e.Name, e.URL); err != nil {
+			return nil, err
+		}
+		// Sort versions to report errors deterministically.
+		for _, version := range slices.Sorted(maps.Keys(e.Versions)) {
+			if err := add(e.Name, e.Versions[version]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return r, nil
+}
+
+// catalogKey returns normalized URL of the catalog schema.
+func catalogKey(u *url.URL) string {
+	k := stripFragment(u)
+	if k.Scheme == "http" {
+		k.Scheme = "https"
+	}
+	k.Host = strings.ToLower(k.Host)
+	return k.String()
+}
+
+// Resolve implements RemoteResolver.
+func (r *CatalogResolver) Resolve(ctx context.Context, loc string) ([]byte, error) {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse location")
+	}
+	file, ok := r.files[catalogKey(u)]
+	if !ok {
+		return nil, errors.Errorf("%q is not in catalog", loc)
+	}
+	return fs.ReadFile(r.fsys, file)
+}
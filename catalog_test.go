@@ -0,0 +1,105 @@
+package jsonschema
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogResolver(t *testing.T) {
+	const catalog = `{
+  "$schema": "https://json.schemastore.org/schema-catalog.json",
+  "version": 1,
+  "schemas": [
+    {
+      "name": "Address",
+      "description": "Postal address",
+      "fileMatch": ["address.json"],
+      "url": "https://json.schemastore.org/address.json"
+    },
+    {
+      "name": "Person",
+      "url": "https://json.schemastore.org/person.json",
+      "versions": {
+        "1.0": "https://json.schemastore.org/person-1.0.json"
+      }
+    },
+    {
+      "name": "Local",
+      "url": "local/local.json"
+    },
+    {
+      "name": "Foo",
+      "url": "https://foo.example.com/schema.json"
+    },
+    {
+      "name": "Bar",
+      "url": "https://bar.example.com/schema.json"
+    }
+  ]
+}`
+	fsys := fstest.MapFS{
+		"json.schemastore.org/address.json": {Data: []byte(`{
+  "type": "object",
+  "required": ["city"]
+}`)},
+		"json.schemastore.org/person.json": {Data: []byte(`{
+  "type": "object",
+  "properties": {
+    "address": {"$ref": "https://json.schemastore.org/address.json"}
+  }
+}`)},
+		"json.schemastore.org/person-1.0.json": {Data: []byte(`{"type": "object"}`)},
+		"local/local.json":                     {Data: []byte(`{"type": "string"}`)},
+		// Schemas with the same name on different hosts.
+		"foo.example.com/schema.json": {Data: []byte(`{"const": "foo"}`)},
+		"bar.example.com/schema.json": {Data: []byte(`{"const": "bar"}`)},
+	}
+
+	a := require.New(t)
+	r, err := NewCatalogResolver([]byte(catalog), fsys)
+	a.NoError(err)
+
+	ctx := context.Background()
+	for _, loc := range []string{
+		"https://json.schemastore.org/address.json",
+		"https://json.schemastore.org/person-1.0.json",
+		"http://json.schemastore.org/person-1.0.json",
+		"https://JSON.schemastore.org/address.json#/properties",
+		"local/local.json",
+	} {
+		_, err := r.Resolve(ctx, loc)
+		a.NoError(err, loc)
+	}
+	_, err = r.Resolve(ctx, "https://json.schemastore.org/unknown.json")
+	a.Error(err)
+
+	for _, name := range []string{"foo", "bar"} {
+		data, err := r.Resolve(ctx, "https://"+name+".example.com/schema.json")
+		a.NoError(err)
+		a.JSONEq(`{"const": "`+name+`"}`, string(data))
+	}
+
+	// The same schema may be listed by both "http" and "https" URLs.
+	_, err = NewCatalogResolver([]byte(`{"schemas": [
+		{"name": "Address", "url": "https://json.schemastore.org/address.json"},
+		{"name": "Address", "url": "http://json.schemastore.org/address.json"}
+	]}`), fsys)
+	a.NoError(err)
+
+	s, err := NewCompiler(Options{Remote: r}).CompileResource("https://json.schemastore.org/person.json")
+	a.NoError(err)
+	a.NoError(s.Validate([]byte(`{"address": {"city": "Moscow"}}`)))
+	a.Error(s.Validate([]byte(`{"address": {}}`)))
+
+	for _, invalid := range []string{
+		`{`,
+		`{"schemas": [{"name": "foo", "url": "https://example.com/"}]}`,
+		`{"schemas": [{"name": "foo", "url": "../foo.json"}]}`,
+	} {
+		_, err := NewCatalogResolver([]byte(invalid), fsys)
+		a.Error(err, invalid)
+	}
+}
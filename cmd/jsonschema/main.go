@@ -0,0 +1,157 @@
+// Command jsonschema validates JSON documents, newline-delimited JSON and
+// JSON text sequences against JSON Schema.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+
+	"github.com/tdakkota/jsonschema"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// Exit codes.
+const (
+	exitValid   = 0
+	exitInvalid = 1
+	exitError   = 2
+)
+
+var formats = map[string]jsonschema.StreamFormat{
+	jsonschema.NDJSON.String():  jsonschema.NDJSON,
+	jsonschema.JSONSeq.String(): jsonschema.JSONSeq,
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	set := flag.NewFlagSet("jsonschema", flag.ContinueOnError)
+	set.SetOutput(stderr)
+	set.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: jsonschema [flags] <schema> [file...]\n\n"+
+			"Validates JSON files against JSON Schema. Reads standard input, if no files given.\n\n"+
+			"Flags:\n")
+		set.PrintDefaults()
+	}
+	var (
+		format  = set.String("format", "json", "input format: json, ndjson or json-seq")
+		workers = set.Int("workers", runtime.GOMAXPROCS(0), "number of concurrently validated records")
+		all     = set.Bool("all", false, "report all errors instead of the first one")
+	)
+	if err := set.Parse(args); err != nil {
+		return exitError
+	}
+	if set.NArg() < 1 {
+		set.Usage()
+		return exitError
+	}
+
+	fail := func(err error) int {
+		_, _ = fmt.Fprintf(stderr, "jsonschema: %v\n", err)
+		return exitError
+	}
+
+	schemaData, err := os.ReadFile(set.Arg(0))
+	if err != nil {
+		return fail(err)
+	}
+	schema, err := jsonschema.Parse(schemaData)
+	if err != nil {
+		return fail(fmt.Errorf("parse schema: %w", err))
+	}
+
+	var validate func(name string, r io.Reader) (bool, error)
+	if *format == "json" {
+		validate = func(name string, r io.Reader) (bool, error) {
+			var err error
+			if *all {
+				data, rerr := io.ReadAll(r)
+				if rerr != nil {
+					return false, rerr
+				}
+				err = schema.ValidateAll(data)
+			} else {
+				err = schema.ValidateReader(r)
+			}
+			if err != nil {
+				printErrors(stdout, name, err)
+				return false, nil
+			}
+			return true, nil
+		}
+	} else {
+		f, ok := formats[*format]
+		if !ok {
+			return fail(fmt.Errorf("unknown format %q", *format))
+		}
+		opts := jsonschema.StreamOptions{
+			Format:  f,
+			Workers: *workers,
+			All:     *all,
+		}
+		validate = func(name string, r io.Reader) (bool, error) {
+			valid := true
+			err := schema.ValidateStream(ctx, r, opts, func(rec jsonschema.Record) error {
+				if rec.Err != nil {
+					valid = false
+					printErrors(stdout, fmt.Sprintf("%s:%d", name, rec.Line), rec.Err)
+				}
+				return nil
+			})
+			return valid, err
+		}
+	}
+
+	files := set.Args()[1:]
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	code := exitValid
+	for _, name := range files {
+		valid, err := validateFile(name, stdin, validate)
+		if err != nil {
+			return fail(fmt.Errorf("%s: %w", name, err))
+		}
+		if !valid {
+			code = exitInvalid
+		}
+	}
+	return code
+}
+
+func validateFile(name string, stdin io.Reader, validate func(name string, r io.Reader) (bool, error)) (bool, error) {
+	if name == "-" {
+		return validate("<stdin>", stdin)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return validate(name, f)
+}
+
+// printErrors prints every validation error on separate line.
+func printErrors(w io.Writer, prefix string, err error) {
+	var list jsonschema.ValidationErrors
+	if !errors.As(err, &list) {
+		_, _ = fmt.Fprintf(w, "%s: %v\n", prefix, err)
+		return
+	}
+	for _, e := range list {
+		_, _ = fmt.Fprintf(w, "%s: %v\n", prefix, e)
+	}
+}
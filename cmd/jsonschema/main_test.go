@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(p, []byte(data), 0o600))
+		return p
+	}
+	schema := write("schema.json", `{"type": "object", "required": ["id", "name"]}`)
+	valid := write("valid.json", `{"id": 1, "name": "foo"}`)
+	invalid := write("invalid.json", `{}`)
+
+	for _, tt := range []struct {
+		name   string
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+	}{
+		{"Valid", []string{schema, valid}, "", exitValid, ""},
+		{"Invalid", []string{schema, valid, invalid}, "", exitInvalid,
+			invalid + `: #: required: required property "id" is missing` + "\n"},
+		{"All", []string{"-all", schema, invalid}, "", exitInvalid,
+			invalid + `: #: required: required property "id" is missing` + "\n" +
+				invalid + `: #: required: required property "name" is missing` + "\n"},
+		{"Stdin", []string{schema}, `{"id": 1}`, exitInvalid,
+			`<stdin>: #: required: required property "name" is missing` + "\n"},
+		{"NDJSON", []string{"-format", "ndjson", "-workers", "2", schema, "-"},
+			"{\"id\": 1, \"name\": \"foo\"}\n\n{\"id\": 1}\n", exitInvalid,
+			`<stdin>:3: #: required: required property "name" is missing` + "\n"},
+		{"JSONSeq", []string{"-format", "json-seq", schema},
+			"\x1e{\"id\": 1, \"name\": \"foo\"}\n\x1e{\n\"name\": \"foo\"}\n", exitInvalid,
+			`<stdin>:2: #: required: required property "id" is missing` + "\n"},
+		{"UnknownFormat", []string{"-format", "yaml", schema}, "", exitError, ""},
+		{"NoSchema", nil, "", exitError, ""},
+		{"MissingFile", []string{schema, filepath.Join(dir, "missing.json")}, "", exitError, ""},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(context.Background(), tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			require.Equal(t, tt.code, code, stderr.String())
+			require.Equal(t, tt.stdout, stdout.String())
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package jsonschema
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/go-faster/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type mapResolver map[string]string
+
+func (m mapResolver) Resolve(_ context.Context, loc string) ([]byte, error) {
+	data, ok := m[loc]
+	if !ok {
+		return nil, errors.Errorf("%q not found", loc)
+	}
+	return []byte(data), nil
+}
+
+func TestCompiler_AddResource(t *testing.T) {
+	a := require.New(t)
+
+	c := NewCompiler(Options{Remote: NoRemote{}})
+	a.NoError(c.AddResource("http://example.com/person.json", []byte(`{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"address": {"$ref": "address.json"}
+	},
+	"definitions": {
+		"zip": {"id": "http://example.com/zip.json", "type": "string", "pattern": "^[0-9]{5}$"}
+	}
+}`)))
+	a.NoError(c.AddResource("http://example.com/address.json", []byte(`{
+	"type": "object",
+	"properties": {
+		"zip": {"$ref": "zip.json"}
+	}
+}`)))
+	a.Error(c.AddResource("address.json", []byte(`{}`)))
+	a.Error(c.AddResource("http://example.com/invalid.json", []byte(`{`)))
+
+	sch, err := c.Compile([]byte(`{"items": {"$ref": "http://example.com/person.json"}}`))
+	a.NoError(err)
+	a.NoError(sch.Validate([]byte(`[{"name": "foo", "address": {"zip": "12345"}}]`)))
+	a.Error(sch.Validate([]byte(`[{"name": "foo", "address": {"zip": "123"}}]`)))
+
+	sch, err = c.CompileResource("http://example.com/person.json")
+	a.NoError(err)
+	a.Error(sch.Validate([]byte(`{"name": 1}`)))
+
+	sch, err = c.CompileResource("http://example.com/person.json#/definitions/zip")
+	a.NoError(err)
+	a.NoError(sch.Validate([]byte(`"12345"`)))
+	a.Error(sch.Validate([]byte(`"foo"`)))
+
+	// Not added and remote references are not allowed.
+	_, err = c.CompileResource("http://example.com/unknown.json")
+	a.Error(err)
+}
+
+func TestCompiler_Options(t *testing.T) {
+	t.Run("RegexpEngine", func(t *testing.T) {
+		a := require.New(t)
+
+		var patterns []string
+		c := NewCompiler(Options{
+			RegexpEngine: func(pattern string) (Regexp, error) {
+				patterns = append(patterns, pattern)
+				return regexp.Compile(pattern)
+			},
+		})
+		_, err := c.Compile([]byte(`{"pattern": "^a", "patternProperties": {"^b": {}}}`))
+		a.NoError(err)
+		a.ElementsMatch([]string{"^a", "^b"}, patterns)
+	})
+	t.Run("MaxRefDepth", func(t *testing.T) {
+		a := require.New(t)
+
+		schema := []byte(`{
+	"$ref": "#/definitions/a",
+	"definitions": {
+		"a": {"$ref": "#/definitions/b"},
+		"b": {"$ref": "#/definitions/c"},
+		"c": {"type": "string"}
+	}
+}`)
+		_, err := NewCompiler(Options{MaxRefDepth: 2}).Compile(schema)
+		a.Error(err)
+		_, err = NewCompiler(Options{}).Compile(schema)
+		a.NoError(err)
+	})
+	t.Run("MaxRemotes", func(t *testing.T) {
+		a := require.New(t)
+
+		remote := mapResolver{
+			"http://example.com/a.json": `{"type": "string"}`,
+			"http://example.com/b.json": `{"minLength": 1}`,
+		}
+		schema := []byte(`{"allOf": [
+	{"$ref": "http://example.com/a.json"},
+	{"$ref": "http://example.com/b.json"}
+]}`)
+		_, err := NewCompiler(Options{Remote: remote, MaxRemotes: 1}).Compile(schema)
+		a.Error(err)
+		_, err = NewCompiler(Options{Remote: remote, MaxRemotes: 2}).Compile(schema)
+		a.NoError(err)
+	})
+}
+
+func TestCompiler_RemoteDraft(t *testing.T) {
+	a := require.New(t)
+
+	remote := mapResolver{
+		"http://example.com/inherited.json": `{"dependentRequired": {"a": ["b"]}}`,
+		"http://example.com/draft4.json": `{
+	"$schema": "http://json-schema.org/draft-04/schema#",
+	"dependentRequired": {"a": ["b"]}
+}`,
+	}
+	c := NewCompiler(Options{Remote: remote})
+
+	// Remote document without "$schema" inherits draft of the referencing
+	// document.
+	sch, err := c.Compile([]byte(`{
+	"$schema": "https://json-schema.org/draft/2019-09/schema",
+	"$ref": "http://example.com/inherited.json"
+}`))
+	a.NoError(err)
+	a.Error(sch.Validate([]byte(`{"a": 1}`)))
+	a.NoError(sch.Validate([]byte(`{"a": 1, "b": 2}`)))
+
+	// "dependentRequired" is an unknown keyword in Draft 4.
+	sch, err = c.Compile([]byte(`{
+	"$schema": "https://json-schema.org/draft/2019-09/schema",
+	"$ref": "http://example.com/draft4.json"
+}`))
+	a.NoError(err)
+	a.NoError(sch.Validate([]byte(`{"a": 1}`)))
+}
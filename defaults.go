@@ -0,0 +1,167 @@
+package jsonschema
+
+import (
+	"slices"
+
+	"github.com/go-faster/errors"
+	"github.com/go-faster/jx"
+)
+
+// ValidateAndApplyDefaults returns a copy of given data with missing
+// object properties set to the "default" value of their "properties"
+// subschema, and validates the result.
+//
+// Defaults are taken from the schema and from subschemas applied by
+// "allOf" and "$ref". Defaults are applied to nested values, including
+// inserted default values.
+//
+// Returned data is nil, if data is not a valid JSON. Otherwise, data is
+// returned even if it is not valid against the schema, with error of
+// ValidateAll.
+func (s *Schema) ValidateAndApplyDefaults(data []byte) ([]byte, error) {
+	d := jx.GetDecoder()
+	defer jx.PutDecoder(d)
+
+	d.ResetBytes(data)
+	if err := d.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid json")
+	}
+
+	var e jx.Encoder
+	if err := applyDefaults(&e, s.expand(nil), data, nil); err != nil {
+		return nil, err
+	}
+	r := e.Bytes()
+	return r, s.ValidateAll(r)
+}
+
+// expand appends s and schemas, applied to the same instance by "allOf"
+// and "$ref", to the list.
+func (s *Schema) expand(list []*Schema) []*Schema {
+	if s == nil || slices.Contains(list, s) {
+		return list
+	}
+	list = append(list, s)
+	list = s.ref.expand(list)
+	for _, sub := range s.allOf {
+		list = sub.expand(list)
+	}
+	return list
+}
+
+// propertySchemas returns schemas, applied to the property with given key.
+func propertySchemas(schemas []*Schema, key string) (r []*Schema) {
+	for _, s := range schemas {
+		prop, matched := s.properties[key]
+		if matched {
+			r = prop.expand(r)
+		}
+		for _, pp := range s.patternProperties {
+			if pp.Regexp.Match([]byte(key)) {
+				matched = true
+				r = pp.Schema.expand(r)
+			}
+		}
+		if ap := s.additionalProperties; !matched && ap.isSchema() {
+			r = ap.Schema.expand(r)
+		}
+	}
+	return r
+}
+
+// itemSchemas returns schemas, applied to the item with given index.
+func itemSchemas(schemas []*Schema, idx int) (r []*Schema) {
+	for _, s := range schemas {
+		switch {
+		case s.items.Object != nil:
+			r = s.items.Object.expand(r)
+		case idx < len(s.items.Array):
+			r = s.items.Array[idx].expand(r)
+		case s.additionalItems.isSchema():
+			r = s.additionalItems.Schema.expand(r)
+		}
+	}
+	return r
+}
+
+// applyDefaults writes data to e, adding default values of missing
+// properties.
+//
+// inserted is a list of schemas, whose default values are being written,
+// to not insert recursive defaults infinitely.
+func applyDefaults(e *jx.Encoder, schemas []*Schema, data []byte, inserted []*Schema) error {
+	if len(schemas) == 0 {
+		e.Raw(data)
+		return nil
+	}
+
+	d := jx.DecodeBytes(data)
+	switch d.Next() {
+	case jx.Object:
+		present := map[string]struct{}{}
+		e.ObjStart()
+		if err := d.ObjBytes(func(d *jx.Decoder, key []byte) error {
+			item, err := d.Raw()
+			if err != nil {
+				return err
+			}
+			present[string(key)] = struct{}{}
+
+			e.FieldStart(string(key))
+			return applyDefaults(e, propertySchemas(schemas, string(key)), item, inserted)
+		}); err != nil {
+			return err
+		}
+		for _, s := range schemas {
+			for _, key := range s.propertyOrder {
+				if _, ok := present[key]; ok {
+					continue
+				}
+				props := propertySchemas(schemas, key)
+				def := defaultOf(props)
+				if def == nil || slices.ContainsFunc(inserted, func(s *Schema) bool {
+					// Same schema may be compiled more than once.
+					return s.loc == def.loc
+				}) {
+					continue
+				}
+				present[key] = struct{}{}
+
+				e.FieldStart(key)
+				if err := applyDefaults(e, props, def.defaultValue, append(inserted, def)); err != nil {
+					return errors.Wrapf(err, "default of %q", key)
+				}
+			}
+		}
+		e.ObjEnd()
+		return nil
+	case jx.Array:
+		idx := 0
+		e.ArrStart()
+		if err := d.Arr(func(d *jx.Decoder) error {
+			item, err := d.Raw()
+			if err != nil {
+				return err
+			}
+			idx++
+			return applyDefaults(e, itemSchemas(schemas, idx-1), item, inserted)
+		}); err != nil {
+			return err
+		}
+		e.ArrEnd()
+		return nil
+	default:
+		e.Raw(data)
+		return nil
+	}
+}
+
+// defaultOf returns the first schema with "default" keyword.
+func defaultOf(schemas []*Schema) *Schema {
+	for _, s := range schemas {
+		if s.defaultValue != nil {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_ValidateAndApplyDefaults(t *testing.T) {
+	s, err := ParseWithOptions([]byte(`{
+	"type": "object",
+	"required": ["port"],
+	"properties": {
+		"host": {"type": "string", "default": "localhost"},
+		"port": {"$ref": "#/$defs/port"},
+		"tls": {
+			"type": "object",
+			"default": {},
+			"properties": {
+				"enabled": {"type": "boolean", "default": false}
+			}
+		},
+		"backends": {
+			"type": "array",
+			"items": {
+				"properties": {
+					"weight": {"type": "integer", "default": 1}
+				}
+			}
+		}
+	},
+	"allOf": [
+		{"properties": {"timeout": {"type": "string", "default": "10s"}}},
+		{"$ref": "#/$defs/logging"}
+	],
+	"$defs": {
+		"port": {"type": "integer", "default": 8080},
+		"logging": {
+			"properties": {
+				"level": {"enum": ["debug", "info"], "default": "info"}
+			}
+		}
+	}
+}`), Options{Draft: Draft202012})
+	require.NoError(t, err)
+
+	for i, tt := range []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			`{}`,
+			`{
+	"host": "localhost",
+	"port": 8080,
+	"tls": {"enabled": false},
+	"timeout": "10s",
+	"level": "info"
+}`,
+			false,
+		},
+		{
+			`{"host": "example.com", "tls": {"enabled": true}, "backends": [{}, {"weight": 2}], "level": "debug"}`,
+			`{
+	"host": "example.com",
+	"tls": {"enabled": true},
+	"backends": [{"weight": 1}, {"weight": 2}],
+	"level": "debug",
+	"port": 8080,
+	"timeout": "10s"
+}`,
+			false,
+		},
+		{
+			`{"port": "80"}`,
+			`{
+	"port": "80",
+	"host": "localhost",
+	"tls": {"enabled": false},
+	"timeout": "10s",
+	"level": "info"
+}`,
+			true,
+		},
+		{`1`, `1`, true},
+		{`{`, ``, true},
+	} {
+		got, err := s.ValidateAndApplyDefaults([]byte(tt.input))
+		if tt.wantErr {
+			require.Error(t, err, "test %d", i+1)
+		} else {
+			require.NoError(t, err, "test %d", i+1)
+		}
+		if tt.want == "" {
+			require.Nil(t, got, "test %d", i+1)
+			continue
+		}
+		require.JSONEq(t, tt.want, string(got), "test %d", i+1)
+	}
+}
+
+func TestSchema_ValidateAndApplyDefaults_Draft4Ref(t *testing.T) {
+	a := require.New(t)
+
+	s, err := Parse([]byte(`{
+	"definitions": {
+		"node": {
+			"properties": {
+				"name": {"default": "unnamed"},
+				"children": {"items": {"$ref": "#/definitions/node"}}
+			}
+		}
+	},
+	"$ref": "#/definitions/node"
+}`))
+	a.NoError(err)
+
+	got, err := s.ValidateAndApplyDefaults([]byte(`{"children": [{"children": [{}]}]}`))
+	a.NoError(err)
+	a.JSONEq(`{
+	"name": "unnamed",
+	"children": [{"name": "unnamed", "children": [{"name": "unnamed"}]}]
+}`, string(got))
+}
+
+func TestSchema_ValidateAndApplyDefaults_Recursive(t *testing.T) {
+	a := require.New(t)
+
+	s, err := ParseWithOptions([]byte(`{
+	"properties": {
+		"child": {"$ref": "#", "default": {}}
+	}
+}`), Options{Draft: Draft201909})
+	a.NoError(err)
+
+	got, err := s.ValidateAndApplyDefaults([]byte(`{}`))
+	a.NoError(err)
+	a.JSONEq(`{"child": {}}`, string(got))
+}
@@ -2,38 +2,94 @@ package jsonschema
 
 import (
 	"net/url"
+	"strings"
 
 	"github.com/go-faster/errors"
 	"github.com/go-faster/jx"
 )
 
 type document struct {
-	id   *url.URL
-	data []byte
-	ids  map[string][]byte
+	draft *draft
+	id    *url.URL
+	// anchors is a list of plain name fragments of the root schema.
+	anchors []string
+	// dynamicAnchor is a "$dynamicAnchor" of the root schema.
+	dynamicAnchor string
+	data          []byte
+	ids           map[string][]byte
+	// dynamicAnchors maps schema resource URI to the list of its dynamic
+	// anchors.
+	dynamicAnchors map[string][]string
 }
 
 func (doc *document) resolveID(u *url.URL) ([]byte, bool) {
 	parsedRef := u
 	if doc.id != nil {
-		parsedRef = doc.id.ResolveReference(u)
+		parsedRef = resolveReference(doc.id, u)
 	}
 	v, ok := doc.ids[parsedRef.String()]
 	return v, ok
 }
 
-func (doc *document) resolve(u *url.URL) (*url.URL, []byte, error) {
+// isIdentified reports whether given schema data is a schema resource,
+// identified by given URL.
+func (doc *document) isIdentified(u *url.URL, data []byte) bool {
 	v, ok := doc.resolveID(u)
-	if ok {
-		return u, v, nil
+	if !ok || len(v) != len(data) {
+		return false
+	}
+	return len(v) == 0 || &v[0] == &data[0]
+}
+
+// lookup finds schema by given URL using identifiers of embedded resources.
+func (doc *document) lookup(u *url.URL) (*url.URL, []byte, bool, error) {
+	if v, ok := doc.resolveID(u); ok {
+		return u, v, true, nil
+	}
+	if f := u.Fragment; strings.HasPrefix(f, "/") {
+		// Pointer relative to embedded resource.
+		loc := stripFragment(u)
+		if v, ok := doc.resolveID(&loc); ok {
+			r, v, err := findFrom(doc.draft, u, v, false, true)
+			return r, v, true, err
+		}
+	}
+	return nil, nil, false, nil
+}
+
+func (doc *document) resolve(u *url.URL) (*url.URL, []byte, error) {
+	if r, v, ok, err := doc.lookup(u); ok {
+		return r, v, err
 	}
-	return find(u, doc.data, false)
+	return find(doc.draft, u, doc.data, false)
 }
 
 func (doc *document) findID(d *jx.Decoder, base *url.URL) error {
-	return d.ObjBytes(func(d *jx.Decoder, key []byte) error {
-		if string(key) != "id" {
-			// TODO(tdakkota): get id field name from draft struct
+	var (
+		id  *url.URL
+		ref bool
+	)
+	if err := d.ObjBytes(func(d *jx.Decoder, key []byte) error {
+		switch string(key) {
+		case "$ref":
+			ref = true
+			return d.Skip()
+		case "$anchor", "$dynamicAnchor":
+			if string(key) == "$anchor" && doc.draft.version < Draft201909 ||
+				string(key) == "$dynamicAnchor" && doc.draft.version < Draft202012 {
+				return d.Skip()
+			}
+			val, err := d.Str()
+			if err != nil {
+				return err
+			}
+			doc.anchors = append(doc.anchors, val)
+			if string(key) == "$dynamicAnchor" {
+				doc.dynamicAnchor = val
+			}
+			return nil
+		case doc.draft.id:
+		default:
 			return d.Skip()
 		}
 
@@ -42,34 +98,72 @@ func (doc *document) findID(d *jx.Decoder, base *url.URL) error {
 			return err
 		}
 
-		id, err := url.Parse(val)
+		id, err = url.Parse(val)
 		if err != nil {
 			return err
 		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if ref && doc.draft.refOverrides() {
+		// "$ref" overrides all sibling keywords, including identifier.
+		return nil
+	}
 
+	if id != nil {
 		doc.id = id
 		if base != nil {
-			doc.id = base.ResolveReference(id)
+			doc.id = resolveReference(base, id)
 		}
+	}
+	return nil
+}
 
-		return nil
-	})
+// resourceURL returns URI of the schema resource, containing the root schema.
+func (doc *document) resourceURL(base *url.URL) string {
+	b := doc.id
+	if b == nil {
+		b = base
+	}
+	if b == nil {
+		return ""
+	}
+	u := stripFragment(b)
+	return u.String()
 }
 
-func collectIDs(base *url.URL, data []byte) (*document, error) {
+func collectIDs(dr *draft, base *url.URL, data []byte) (*document, error) {
 	root := &document{
-		id:   nil,
-		data: data,
-		ids:  map[string][]byte{},
+		draft: dr,
+		id:    nil,
+		data:  data,
+		ids:   map[string][]byte{},
+
+		dynamicAnchors: map[string][]string{},
 	}
 
 	rootd := jx.DecodeBytes(data)
+	if rootd.Next() != jx.Object && dr.boolSchemas {
+		// Boolean schema.
+		return root, nil
+	}
 	if err := root.findID(rootd, base); err != nil {
 		return nil, errors.Wrap(err, "find ID")
 	}
 	if root.id != nil {
 		root.ids[root.id.String()] = root.data
 	}
+	if len(root.anchors) > 0 {
+		loc := root.resourceURL(base)
+		for _, anchor := range root.anchors {
+			// Anchor is a plain name fragment of the current base URI.
+			root.ids[loc+"#"+anchor] = root.data
+		}
+		if a := root.dynamicAnchor; a != "" {
+			root.dynamicAnchors[loc] = append(root.dynamicAnchors[loc], a)
+		}
+	}
 
 	do := func(d *jx.Decoder) error {
 		if d.Next() != jx.Object {
@@ -83,7 +177,7 @@ func collectIDs(base *url.URL, data []byte) (*document, error) {
 		if b == nil {
 			b = base
 		}
-		sub, err := collectIDs(b, raw)
+		sub, err := collectIDs(dr, b, raw)
 		if err != nil {
 			return err
 		}
@@ -94,6 +188,9 @@ func collectIDs(base *url.URL, data []byte) (*document, error) {
 		for k, v := range sub.ids {
 			root.ids[k] = v
 		}
+		for k, v := range sub.dynamicAnchors {
+			root.dynamicAnchors[k] = append(root.dynamicAnchors[k], v...)
+		}
 		return nil
 	}
 	doObj := func(d *jx.Decoder) error {
@@ -112,14 +209,14 @@ func collectIDs(base *url.URL, data []byte) (*document, error) {
 
 	rootd.ResetBytes(data)
 	if err := rootd.ObjBytes(func(d *jx.Decoder, key []byte) error {
-		switch string(key) {
-		case "definitions", "properties", "patternProperties", "dependencies":
+		switch dr.subschemas[string(key)] {
+		case schemaMap:
 			return doObj(d)
-		case "additionalItems", "additionalProperties", "not":
+		case singleSchema:
 			return do(d)
-		case "allOf", "anyOf", "oneOf":
+		case schemaArray:
 			return doArr(d)
-		case "items":
+		case singleOrArray:
 			switch d.Next() {
 			case jx.Array:
 				return doArr(d)
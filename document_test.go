@@ -17,17 +17,17 @@ func Test_collectIDs(t *testing.T) {
             }
         }`)
 
-	d, err := collectIDs(nil, root)
+	d, err := collectIDs(draft4, nil, root)
 	a.NoError(err)
 	a.NotEmpty(d.ids)
 	a.NotEmpty(d.ids["http://localhost:1234/baseUriChange/"])
 
-	d, err = collectIDs(nil, []byte(`{"definitions": null}`))
+	d, err = collectIDs(draft4, nil, []byte(`{"definitions": null}`))
 	a.NoError(err)
 	a.Empty(d.ids)
 }
 
 func Test_document_findID(t *testing.T) {
-	doc := &document{}
+	doc := &document{draft: draft4}
 	require.Error(t, doc.findID(jx.DecodeStr(`{"id": null}`), nil))
 }
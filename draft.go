@@ -0,0 +1,268 @@
+package jsonschema
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-faster/jx"
+)
+
+// Draft is a JSON Schema specification version.
+type Draft int
+
+const (
+	// Draft4 is JSON Schema Draft 4.
+	//
+	// See https://datatracker.ietf.org/doc/html/draft-fge-json-schema-validation-00.
+	Draft4 Draft = 4
+	// Draft6 is JSON Schema Draft 6.
+	//
+	// See https://datatracker.ietf.org/doc/html/draft-wright-json-schema-validation-01.
+	Draft6 Draft = 6
+	// Draft7 is JSON Schema Draft 7.
+	//
+	// See https://datatracker.ietf.org/doc/html/draft-handrews-json-schema-validation-01.
+	Draft7 Draft = 7
+	// Draft201909 is JSON Schema Draft 2019-09.
+	//
+	// See https://json-schema.org/draft/2019-09/json-schema-validation.html.
+	Draft201909 Draft = 2019
+	// Draft202012 is JSON Schema Draft 2020-12.
+	//
+	// See https://json-schema.org/draft/2020-12/json-schema-validation.html.
+	Draft202012 Draft = 2020
+)
+
+// String implements fmt.Stringer.
+func (d Draft) String() string {
+	switch d {
+	case Draft201909:
+		return "draft2019-09"
+	case Draft202012:
+		return "draft2020-12"
+	default:
+		return "draft" + strconv.Itoa(int(d))
+	}
+}
+
+// subschemaKind defines how keyword contains subschemas.
+type subschemaKind uint8
+
+const (
+	// singleSchema is a keyword with schema value, like "not".
+	singleSchema subschemaKind = iota + 1
+	// schemaMap is a keyword with object of schemas, like "properties".
+	schemaMap
+	// schemaArray is a keyword with array of schemas, like "allOf".
+	schemaArray
+	// singleOrArray is a keyword with schema or array of schemas, like "items".
+	singleOrArray
+)
+
+// draft describes differences between JSON Schema drafts.
+type draft struct {
+	version Draft
+	// meta is an URI of the draft meta-schema.
+	meta string
+	// id is the name of identifier keyword.
+	id string
+	// boolSchemas is set if draft allows boolean schemas.
+	boolSchemas bool
+	// subschemas maps keywords to the kind of their subschemas.
+	subschemas map[string]subschemaKind
+	// formats is a set of formats defined by draft.
+	formats map[string]FormatFunc
+}
+
+// refOverrides reports whether "$ref" overrides sibling keywords.
+func (d *draft) refOverrides() bool {
+	return d.version < Draft201909
+}
+
+// rawID returns identifier of given schema.
+func (d *draft) rawID(s RawSchema) string {
+	if d.id == "id" {
+		return s.ID
+	}
+	return s.DollarID
+}
+
+var (
+	draft4 = &draft{
+		version:     Draft4,
+		meta:        "http://json-schema.org/draft-04/schema",
+		id:          "id",
+		boolSchemas: false,
+		subschemas: map[string]subschemaKind{
+			"definitions":          schemaMap,
+			"properties":           schemaMap,
+			"patternProperties":    schemaMap,
+			"dependencies":         schemaMap,
+			"additionalItems":      singleSchema,
+			"additionalProperties": singleSchema,
+			"not":                  singleSchema,
+			"allOf":                schemaArray,
+			"anyOf":                schemaArray,
+			"oneOf":                schemaArray,
+			"items":                singleOrArray,
+		},
+		formats: draft4Formats,
+	}
+	draft6 = &draft{
+		version:     Draft6,
+		meta:        "http://json-schema.org/draft-06/schema",
+		id:          "$id",
+		boolSchemas: true,
+		subschemas: extendSubschemas(draft4.subschemas, map[string]subschemaKind{
+			"contains":      singleSchema,
+			"propertyNames": singleSchema,
+		}),
+		formats: extendFormats(draft4Formats, map[string]FormatFunc{
+			"uri-reference": checkURIReference,
+			"uri-template":  checkURITemplate,
+			"json-pointer":  checkJSONPointer,
+		}),
+	}
+	draft7 = &draft{
+		version:     Draft7,
+		meta:        "http://json-schema.org/draft-07/schema",
+		id:          "$id",
+		boolSchemas: true,
+		subschemas: extendSubschemas(draft6.subschemas, map[string]subschemaKind{
+			"if":   singleSchema,
+			"then": singleSchema,
+			"else": singleSchema,
+		}),
+		formats: extendFormats(draft6.formats, map[string]FormatFunc{
+			"date":                  checkDate,
+			"time":                  checkTime,
+			"iri":                   checkURI,
+			"iri-reference":         checkURIReference,
+			"idn-email":             checkEmail,
+			"idn-hostname":          checkIDNHostname,
+			"relative-json-pointer": checkRelativeJSONPointer,
+			"regex":                 checkRegex,
+		}),
+	}
+	draft201909 = &draft{
+		version:     Draft201909,
+		meta:        "https://json-schema.org/draft/2019-09/schema",
+		id:          "$id",
+		boolSchemas: true,
+		subschemas: removeSubschemas(extendSubschemas(draft7.subschemas, map[string]subschemaKind{
+			"$defs":                 schemaMap,
+			"dependentSchemas":      schemaMap,
+			"unevaluatedItems":      singleSchema,
+			"unevaluatedProperties": singleSchema,
+			"contentSchema":         singleSchema,
+		}),
+			// Split into "dependentSchemas" and "dependentRequired".
+			"dependencies",
+		),
+		formats: extendFormats(draft7.formats, map[string]FormatFunc{
+			"duration": checkDuration,
+			"uuid":     checkUUID,
+		}),
+	}
+	draft202012 = &draft{
+		version:     Draft202012,
+		meta:        "https://json-schema.org/draft/2020-12/schema",
+		id:          "$id",
+		boolSchemas: true,
+		subschemas: removeSubschemas(extendSubschemas(draft201909.subschemas, map[string]subschemaKind{
+			"prefixItems": schemaArray,
+			// "items" is a single schema since Draft 2020-12.
+			"items": singleSchema,
+		}),
+			// Replaced by "items".
+			"additionalItems",
+		),
+		formats: draft201909.formats,
+	}
+	drafts = map[Draft]*draft{
+		Draft4:      draft4,
+		Draft6:      draft6,
+		Draft7:      draft7,
+		Draft201909: draft201909,
+		Draft202012: draft202012,
+	}
+)
+
+// metaSchemaDrafts maps meta-schema URIs without scheme and fragment
+// to drafts.
+var metaSchemaDrafts = map[string]Draft{
+	"json-schema.org/draft-04/schema":      Draft4,
+	"json-schema.org/draft-06/schema":      Draft6,
+	"json-schema.org/draft-07/schema":      Draft7,
+	"json-schema.org/draft/2019-09/schema": Draft201909,
+	"json-schema.org/draft/2020-12/schema": Draft202012,
+}
+
+// metaSchemaDraft returns draft of given meta-schema URI, the value of
+// "$schema" keyword.
+//
+// Both "http" and "https" schemes are accepted, empty fragment is ignored.
+func metaSchemaDraft(uri string) (Draft, bool) {
+	uri = strings.TrimSuffix(uri, "#")
+	for _, scheme := range []string{"https://", "http://"} {
+		if rest, ok := strings.CutPrefix(uri, scheme); ok {
+			d, ok := metaSchemaDrafts[rest]
+			return d, ok
+		}
+	}
+	return 0, false
+}
+
+// schemaURI returns value of "$schema" keyword of given schema.
+//
+// Returns empty string, if schema is not an object or keyword is not set.
+func schemaURI(data []byte) (uri string, _ error) {
+	d := jx.DecodeBytes(data)
+	if d.Next() != jx.Object {
+		return "", nil
+	}
+	err := d.ObjBytes(func(d *jx.Decoder, key []byte) error {
+		if string(key) != "$schema" || d.Next() != jx.String {
+			return d.Skip()
+		}
+		val, err := d.Str()
+		if err != nil {
+			return err
+		}
+		uri = val
+		return nil
+	})
+	return uri, err
+}
+
+func extendSubschemas(base, add map[string]subschemaKind) map[string]subschemaKind {
+	r := make(map[string]subschemaKind, len(base)+len(add))
+	for k, v := range base {
+		r[k] = v
+	}
+	for k, v := range add {
+		r[k] = v
+	}
+	return r
+}
+
+// removeSubschemas returns a copy of base without given keywords, which
+// are unknown keywords for the draft.
+func removeSubschemas(base map[string]subschemaKind, keywords ...string) map[string]subschemaKind {
+	r := extendSubschemas(base, nil)
+	for _, k := range keywords {
+		delete(r, k)
+	}
+	return r
+}
+
+func extendFormats(base, add map[string]FormatFunc) map[string]FormatFunc {
+	r := make(map[string]FormatFunc, len(base)+len(add))
+	for k, v := range base {
+		r[k] = v
+	}
+	for k, v := range add {
+		r[k] = v
+	}
+	return r
+}
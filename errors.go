@@ -0,0 +1,170 @@
+package jsonschema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValidationError describes failed keyword validation.
+type ValidationError struct {
+	// InstanceLocation is a JSON Pointer to the invalid value.
+	InstanceLocation string
+	// KeywordLocation is a JSON Pointer to the failed keyword, relative to
+	// the root schema.
+	//
+	// Includes "$ref" tokens of followed references.
+	KeywordLocation string
+	// AbsoluteKeywordLocation is an absolute URI of the failed keyword
+	// after references resolution.
+	AbsoluteKeywordLocation string
+	// Keyword is the name of the failed keyword.
+	//
+	// Empty, if error is caused by false boolean schema.
+	Keyword string
+	// Expected is the value required by the keyword, if any.
+	Expected any
+	// Actual is the value found in the instance, if any.
+	Actual any
+	// Message is a human-readable error description.
+	Message string
+	// Causes is a list of subschema errors, that caused this error.
+	//
+	// Set by composition keywords, like "anyOf" or "oneOf".
+	Causes []*ValidationError
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	if e.Keyword == "" {
+		return fmt.Sprintf("#%s: %s", e.InstanceLocation, e.Message)
+	}
+	return fmt.Sprintf("#%s: %s: %s", e.InstanceLocation, e.Keyword, e.Message)
+}
+
+// ValidationErrors is a list of validation errors.
+//
+// Returned by ValidateAll.
+type ValidationErrors []*ValidationError
+
+// Error implements error.
+func (e ValidationErrors) Error() string {
+	var b strings.Builder
+	for i, err := range e {
+		if i != 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns list of errors.
+func (e ValidationErrors) Unwrap() []error {
+	r := make([]error, len(e))
+	for i, err := range e {
+		r[i] = err
+	}
+	return r
+}
+
+// SchemaError is returned by Parse, if schema is not valid against its
+// meta-schema.
+type SchemaError struct {
+	// URI is an URI of the invalid schema document.
+	//
+	// Empty for the parsed document.
+	URI string
+	// MetaSchema is an URI of the meta-schema.
+	MetaSchema string
+	// Errors is a list of meta-schema validation errors.
+	//
+	// InstanceLocation of each error is a JSON Pointer to the invalid
+	// schema location.
+	Errors ValidationErrors
+}
+
+// Error implements error.
+func (e *SchemaError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid schema")
+	if e.URI != "" {
+		b.WriteString(" ")
+		b.WriteString(strconv.Quote(e.URI))
+	}
+	for i, err := range e.Errors {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns list of errors.
+func (e *SchemaError) Unwrap() []error {
+	return e.Errors.Unwrap()
+}
+
+// asValidationErrors converts given error to list of validation errors.
+//
+// Returns false, if err is not a validation error, e.g. syntax error.
+func asValidationErrors(err error) (ValidationErrors, bool) {
+	switch err := err.(type) {
+	case *ValidationError:
+		return ValidationErrors{err}, true
+	case ValidationErrors:
+		return err, true
+	case errorList:
+		r := make(ValidationErrors, 0, len(err))
+		for _, e := range err {
+			list, ok := asValidationErrors(e)
+			if !ok {
+				return nil, false
+			}
+			r = append(r, list...)
+		}
+		return r, true
+	default:
+		return nil, false
+	}
+}
+
+// errorList accumulates errors during validation.
+type errorList []error
+
+// Error implements error.
+func (e errorList) Error() string {
+	var b strings.Builder
+	for i, err := range e {
+		if i != 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// add appends given error to the list, flattening nested lists.
+func (e *errorList) add(err error) {
+	if list, ok := err.(errorList); ok {
+		*e = append(*e, list...)
+		return
+	}
+	*e = append(*e, err)
+}
+
+// err returns nil if list is empty, the only error if list contains one error
+// and the list itself otherwise.
+func (e errorList) err() error {
+	switch len(e) {
+	case 0:
+		return nil
+	case 1:
+		return e[0]
+	default:
+		return e
+	}
+}
@@ -0,0 +1,387 @@
+package jsonschema
+
+import (
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-faster/errors"
+)
+
+// FormatFunc checks that string value is valid in some format.
+//
+// Given value is a raw unescaped string. Function must not retain it.
+type FormatFunc func(v []byte) error
+
+// UnknownFormat defines behavior on unknown format.
+type UnknownFormat int
+
+const (
+	// IgnoreUnknownFormat silently ignores unknown formats.
+	IgnoreUnknownFormat UnknownFormat = iota
+	// WarnUnknownFormat reports unknown formats using Options.Warn.
+	WarnUnknownFormat
+	// RejectUnknownFormat fails compilation on unknown formats.
+	RejectUnknownFormat
+)
+
+// String implements fmt.Stringer.
+func (f UnknownFormat) String() string {
+	switch f {
+	case IgnoreUnknownFormat:
+		return "ignore"
+	case WarnUnknownFormat:
+		return "warn"
+	case RejectUnknownFormat:
+		return "reject"
+	default:
+		return "unknown"
+	}
+}
+
+// draft4Formats is a list of formats defined by Draft 4.
+//
+// See https://datatracker.ietf.org/doc/html/draft-fge-json-schema-validation-00#section-7.3.
+var draft4Formats = map[string]FormatFunc{
+	"date-time": checkDateTime,
+	"email":     checkEmail,
+	"hostname":  checkHostname,
+	"ipv4":      checkIPv4,
+	"ipv6":      checkIPv6,
+	"uri":       checkURI,
+}
+
+// checkDateTime checks RFC 3339 date-time.
+func checkDateTime(v []byte) error {
+	s := strings.ToUpper(string(v))
+	// RFC 3339 allows leap second, but time package does not.
+	//
+	// Format is "YYYY-MM-DDThh:mm:ss", so seconds are always at the same position.
+	leap := len(s) > 19 && s[17:19] == "60"
+	if leap {
+		s = s[:17] + "59" + s[19:]
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return err
+	}
+	// time package accepts offset hour 24 and offset minute 60.
+	if n := len(s); s[n-6] == '+' || s[n-6] == '-' {
+		if s[n-5:n-3] > "23" || s[n-2:] > "59" {
+			return errors.Errorf("invalid time offset %q", s[n-6:])
+		}
+	}
+	// Leap second is inserted at the end of UTC day.
+	if u := t.UTC(); leap && (u.Hour() != 23 || u.Minute() != 59) {
+		return errors.New("invalid leap second")
+	}
+	return nil
+}
+
+// checkDate checks RFC 3339 full-date.
+func checkDate(v []byte) error {
+	if _, err := time.Parse("2006-01-02", string(v)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkTime checks RFC 3339 full-time.
+func checkTime(v []byte) error {
+	// Use date-time check with dummy date.
+	return checkDateTime(append([]byte("1970-01-01T"), v...))
+}
+
+// checkEmail checks RFC 5322 address.
+func checkEmail(v []byte) error {
+	s := string(v)
+	addr, err := mail.ParseAddress(s)
+	if err != nil {
+		return err
+	}
+	if addr.Name != "" || addr.Address != s {
+		return errors.New("address must not contain name")
+	}
+	return nil
+}
+
+// checkHostname checks RFC 1034 hostname.
+func checkHostname(v []byte) error {
+	s := strings.TrimSuffix(string(v), ".")
+	if len(s) == 0 || len(s) > 253 {
+		return errors.Errorf("invalid length %d", len(s))
+	}
+	for _, label := range strings.Split(s, ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return errors.Errorf("invalid label length %d", len(label))
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return errors.Errorf("label %q must not start or end with hyphen", label)
+		}
+		for _, c := range []byte(label) {
+			switch {
+			case c >= 'a' && c <= 'z',
+				c >= 'A' && c <= 'Z',
+				c >= '0' && c <= '9',
+				c == '-':
+			default:
+				return errors.Errorf("label %q contains invalid character %q", label, c)
+			}
+		}
+	}
+	return nil
+}
+
+// checkIDNHostname checks RFC 5890 internationalized hostname.
+func checkIDNHostname(v []byte) error {
+	s := strings.TrimSuffix(string(v), ".")
+	if len(s) == 0 || utf8.RuneCountInString(s) > 253 {
+		return errors.Errorf("invalid length %d", len(s))
+	}
+	for _, label := range strings.Split(s, ".") {
+		if n := utf8.RuneCountInString(label); n == 0 || n > 63 {
+			return errors.Errorf("invalid label length %d", n)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return errors.Errorf("label %q must not start or end with hyphen", label)
+		}
+		if r, _ := utf8.DecodeRuneInString(label); unicode.Is(unicode.M, r) {
+			return errors.Errorf("label %q must not start with combining mark", label)
+		}
+		for _, r := range label {
+			switch {
+			case r == '-',
+				unicode.IsLetter(r),
+				unicode.IsDigit(r),
+				unicode.Is(unicode.Mn, r),
+				unicode.Is(unicode.Mc, r):
+			default:
+				return errors.Errorf("label %q contains invalid character %q", label, r)
+			}
+		}
+	}
+	return nil
+}
+
+// checkIPv4 checks dotted-quad IPv4 address.
+func checkIPv4(v []byte) error {
+	addr, err := netip.ParseAddr(string(v))
+	if err != nil {
+		return err
+	}
+	if !addr.Is4() {
+		return errors.New("not an IPv4 address")
+	}
+	return nil
+}
+
+// checkIPv6 checks RFC 4291 IPv6 address.
+func checkIPv6(v []byte) error {
+	addr, err := netip.ParseAddr(string(v))
+	if err != nil {
+		return err
+	}
+	if !addr.Is6() || addr.Zone() != "" {
+		return errors.New("not an IPv6 address")
+	}
+	return nil
+}
+
+// uriInvalidChars is a set of characters, not allowed in URI.
+const uriInvalidChars = " \"<>\\^`{|}"
+
+// checkURI checks RFC 3986 absolute URI.
+func checkURI(v []byte) error {
+	u, err := parseURIReference(v)
+	if err != nil {
+		return err
+	}
+	if !u.IsAbs() {
+		return errors.New("URI must be absolute")
+	}
+	return nil
+}
+
+// checkURIReference checks RFC 3986 URI reference.
+func checkURIReference(v []byte) error {
+	_, err := parseURIReference(v)
+	return err
+}
+
+func parseURIReference(v []byte) (*url.URL, error) {
+	s := string(v)
+	if idx := strings.IndexAny(s, uriInvalidChars); idx >= 0 {
+		return nil, errors.Errorf("invalid character %q", s[idx])
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	// IPv6 address must be enclosed in brackets.
+	if !strings.HasPrefix(u.Host, "[") && strings.Count(u.Host, ":") > 1 {
+		return nil, errors.Errorf("invalid host %q", u.Host)
+	}
+	return u, nil
+}
+
+// checkURITemplate checks RFC 6570 URI template.
+func checkURITemplate(v []byte) error {
+	open := false
+	for i, c := range v {
+		switch c {
+		case '{':
+			if open {
+				return errors.Errorf("unexpected '{' at %d", i)
+			}
+			open = true
+		case '}':
+			if !open {
+				return errors.Errorf("unexpected '}' at %d", i)
+			}
+			open = false
+		}
+	}
+	if open {
+		return errors.New("unclosed expression")
+	}
+	return nil
+}
+
+// checkJSONPointer checks RFC 6901 JSON Pointer.
+func checkJSONPointer(v []byte) error {
+	if len(v) > 0 && v[0] != '/' {
+		return errors.New("pointer must start with '/'")
+	}
+	for i, c := range v {
+		if c != '~' {
+			continue
+		}
+		if i+1 >= len(v) || (v[i+1] != '0' && v[i+1] != '1') {
+			return errors.Errorf("invalid escape at %d", i)
+		}
+	}
+	return nil
+}
+
+// checkRelativeJSONPointer checks relative JSON Pointer.
+//
+// See https://datatracker.ietf.org/doc/html/draft-handrews-relative-json-pointer-01.
+func checkRelativeJSONPointer(v []byte) error {
+	i := 0
+	for i < len(v) && v[i] >= '0' && v[i] <= '9' {
+		i++
+	}
+	switch {
+	case i == 0:
+		return errors.New("pointer must start with non-negative integer")
+	case i > 1 && v[0] == '0':
+		return errors.New("leading zeros are not allowed")
+	}
+	if rest := v[i:]; len(rest) != 1 || rest[0] != '#' {
+		return checkJSONPointer(rest)
+	}
+	return nil
+}
+
+// checkRegex checks regular expression.
+func checkRegex(v []byte) error {
+	_, err := regexp.Compile(string(v))
+	return err
+}
+
+// checkDuration checks ISO 8601 duration, as defined by RFC 3339 Appendix A.
+func checkDuration(v []byte) error {
+	s := string(v)
+	if !strings.HasPrefix(s, "P") || len(s) < 2 {
+		return errors.New("duration must start with 'P'")
+	}
+	s = s[1:]
+
+	// parse parses sequence of number-unit pairs, units must be in given order.
+	parse := func(s, units string) (string, error) {
+		for len(s) > 0 {
+			i := 0
+			for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+				i++
+			}
+			if i == 0 || i == len(s) {
+				break
+			}
+			idx := strings.IndexByte(units, s[i])
+			if idx < 0 {
+				return "", errors.Errorf("unexpected unit %q", s[i])
+			}
+			units = units[idx+1:]
+			s = s[i+1:]
+		}
+		return s, nil
+	}
+
+	date, timePart, hasTime := strings.Cut(s, "T")
+	if strings.HasSuffix(date, "W") {
+		// Week duration can't be combined with others.
+		if hasTime {
+			return errors.New("week duration can't contain time")
+		}
+		rest, err := parse(date, "W")
+		if err != nil {
+			return err
+		}
+		if rest != "" {
+			return errors.Errorf("unexpected %q", rest)
+		}
+		return nil
+	}
+
+	rest, err := parse(date, "YMD")
+	if err != nil {
+		return err
+	}
+	if rest != "" {
+		return errors.Errorf("unexpected %q", rest)
+	}
+	if hasTime {
+		if timePart == "" {
+			return errors.New("empty time")
+		}
+		rest, err := parse(timePart, "HMS")
+		if err != nil {
+			return err
+		}
+		if rest != "" {
+			return errors.Errorf("unexpected %q", rest)
+		}
+	} else if date == "" {
+		return errors.New("empty duration")
+	}
+	return nil
+}
+
+// checkUUID checks RFC 4122 UUID.
+func checkUUID(v []byte) error {
+	if len(v) != 36 {
+		return errors.Errorf("invalid length %d", len(v))
+	}
+	for i, c := range v {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return errors.Errorf("expected '-' at %d", i)
+			}
+			continue
+		}
+		switch {
+		case c >= '0' && c <= '9',
+			c >= 'a' && c <= 'f',
+			c >= 'A' && c <= 'F':
+		default:
+			return errors.Errorf("invalid character %q at %d", c, i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package jsonschema
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/go-faster/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		draft  Draft
+		format string
+		value  string
+		valid  bool
+	}{
+		{Draft4, "date-time", "1963-06-19T08:30:06.283185Z", true},
+		{Draft4, "date-time", "1963-06-19t08:30:06z", true},
+		{Draft4, "date-time", "1990-12-31T15:59:60-08:00", true},
+		{Draft4, "date-time", "1998-12-31T23:58:60Z", false},
+		{Draft4, "date-time", "1990-02-31T15:59:59.123-08:00", false},
+		{Draft4, "date-time", "06/19/1963 08:30:06 PST", false},
+		{Draft4, "date-time", "2013-350T01:01:01", false},
+
+		{Draft4, "email", "joe.bloggs@example.com", true},
+		{Draft4, "email", "te~st@example.com", true},
+		{Draft4, "email", "2962", false},
+		{Draft4, "email", "Joe <joe.bloggs@example.com>", false},
+
+		{Draft4, "hostname", "www.example.com", true},
+		{Draft4, "hostname", "xn--4gbwdl.xn--wgbh1c", true},
+		{Draft4, "hostname", "-a-host-name-that-starts-with--", false},
+		{Draft4, "hostname", "not_a_valid_host_name", false},
+		{Draft4, "hostname", "a-vvvvvvvvvvvvvvvveeeeeeeeeeeeeeeerrrrrrrrrrrrrrrryyyyyyyyyyyyyyyy-long-host-name-component", false},
+
+		{Draft4, "ipv4", "192.168.0.1", true},
+		{Draft4, "ipv4", "127.0.0.0.1", false},
+		{Draft4, "ipv4", "256.256.256.256", false},
+		{Draft4, "ipv4", "0x7f000001", false},
+		{Draft4, "ipv4", "::1", false},
+
+		{Draft4, "ipv6", "::1", true},
+		{Draft4, "ipv6", "::abef", true},
+		{Draft4, "ipv6", "12345::", false},
+		{Draft4, "ipv6", "::laptop", false},
+		{Draft4, "ipv6", "127.0.0.1", false},
+
+		{Draft4, "uri", "http://foo.bar/?baz=qux#quux", true},
+		{Draft4, "uri", "mailto:John.Doe@example.com", true},
+		{Draft4, "uri", "//foo.bar/?baz=qux#quux", false},
+		{Draft4, "uri", "abc", false},
+
+		// Unknown formats are ignored.
+		{Draft4, "unknown", "abc", true},
+		{Draft4, "uri-reference", "\\\\WINDOWS\\fileshare", true},
+
+		{Draft6, "uri-reference", "//foo.bar/?baz=qux#quux", true},
+		{Draft6, "uri-reference", "\\\\WINDOWS\\fileshare", false},
+		{Draft6, "uri-template", "http://example.com/dictionary/{term:1}/{term}", true},
+		{Draft6, "uri-template", "http://example.com/dictionary/{term:1}/{term", false},
+		{Draft6, "json-pointer", "/foo/bar~0/baz~1/%a", true},
+		{Draft6, "json-pointer", "/foo/bar~", false},
+		{Draft6, "json-pointer", "#/foo", false},
+
+		{Draft7, "date", "1963-06-19", true},
+		{Draft7, "date", "2020-02-30", false},
+		{Draft7, "time", "08:30:06.283185Z", true},
+		{Draft7, "time", "08:30:06", false},
+		{Draft7, "idn-hostname", "실례.테스트", true},
+		{Draft7, "idn-hostname", "〮실례.테스트", false},
+		{Draft7, "relative-json-pointer", "1", true},
+		{Draft7, "relative-json-pointer", "0/foo/bar", true},
+		{Draft7, "relative-json-pointer", "2#", true},
+		{Draft7, "relative-json-pointer", "/foo/bar", false},
+		{Draft7, "relative-json-pointer", "01/a", false},
+		{Draft7, "regex", "([abc])+\\s+$", true},
+		{Draft7, "regex", "^(abc]", false},
+	}
+	for i, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprintf("Test%d", i+1), func(t *testing.T) {
+			a := require.New(t)
+			schema := []byte(fmt.Sprintf(`{"format": %q}`, tt.format))
+			data := []byte(strconv.Quote(tt.value))
+
+			// Format is an annotation by default.
+			annotation, err := ParseWithOptions(schema, Options{Draft: tt.draft})
+			a.NoError(err)
+			a.NoError(annotation.Validate(data))
+
+			assertion, err := ParseWithOptions(schema, Options{Draft: tt.draft, AssertFormat: true})
+			a.NoError(err)
+			if err := assertion.Validate(data); tt.valid {
+				a.NoError(err)
+			} else {
+				var e *ValidationError
+				a.ErrorAs(err, &e)
+				a.Equal("format", e.Keyword)
+				a.Equal(tt.format, e.Expected)
+			}
+		})
+	}
+}
+
+func TestCustomFormat(t *testing.T) {
+	semver := func(v []byte) error {
+		if len(v) == 0 || v[0] != 'v' {
+			return errors.New("version must start with v")
+		}
+		return nil
+	}
+	schema := []byte(`{
+	"properties": {
+		"version": {"format": "semver"},
+		"email": {"format": "email"},
+		"name": {"format": "k8s-name"}
+	}
+}`)
+
+	t.Run("Assert", func(t *testing.T) {
+		a := require.New(t)
+
+		sch, err := ParseWithOptions(schema, Options{
+			AssertFormat: true,
+			Formats: map[string]FormatFunc{
+				"semver": semver,
+				// Override built-in format.
+				"email": func(v []byte) error { return nil },
+			},
+		})
+		a.NoError(err)
+
+		a.NoError(sch.Validate([]byte(`{"version": "v1.0.0", "email": "foo", "name": "bar"}`)))
+		var e *ValidationError
+		a.ErrorAs(sch.Validate([]byte(`{"version": "1.0.0"}`)), &e)
+		a.Equal("/properties/version/format", e.KeywordLocation)
+		a.Equal(`"1.0.0" is not valid semver: version must start with v`, e.Message)
+	})
+	t.Run("Annotation", func(t *testing.T) {
+		a := require.New(t)
+
+		sch, err := ParseWithOptions(schema, Options{
+			Formats: map[string]FormatFunc{
+				"semver": semver,
+			},
+		})
+		a.NoError(err)
+		a.NoError(sch.Validate([]byte(`{"version": "1.0.0"}`)))
+	})
+	t.Run("Warn", func(t *testing.T) {
+		a := require.New(t)
+
+		var warnings []string
+		_, err := ParseWithOptions(schema, Options{
+			Formats: map[string]FormatFunc{
+				"semver": semver,
+			},
+			UnknownFormat: WarnUnknownFormat,
+			Warn: func(loc, msg string) {
+				warnings = append(warnings, loc+": "+msg)
+			},
+		})
+		a.NoError(err)
+		a.Equal([]string{`#/properties/name/format: unknown format "k8s-name"`}, warnings)
+	})
+	t.Run("Reject", func(t *testing.T) {
+		a := require.New(t)
+
+		_, err := ParseWithOptions(schema, Options{
+			UnknownFormat: RejectUnknownFormat,
+		})
+		a.Error(err)
+	})
+}
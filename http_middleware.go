@@ -0,0 +1,387 @@
+package jsonschema
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-faster/errors"
+	"github.com/go-faster/jx"
+)
+
+// DefaultMaxBodySize is a default limit of body size, validated by
+// Middleware.
+const DefaultMaxBodySize = 8 << 20
+
+// Problem is a problem details object, defined by RFC 9457.
+type Problem struct {
+	// Type is an URI reference, that identifies the problem type.
+	//
+	// Empty means "about:blank".
+	Type string
+	// Title is a short summary of the problem type.
+	Title string
+	// Status is a HTTP status code.
+	Status int
+	// Detail is a human-readable explanation of the problem occurrence.
+	Detail string
+	// Instance is an URI reference, that identifies the problem
+	// occurrence.
+	Instance string
+	// Errors is a list of validation errors, encoded as "errors"
+	// extension member.
+	Errors ValidationErrors
+}
+
+// Encode encodes problem as JSON.
+//
+// Every validation error is encoded as an object with "detail" and
+// "pointer" members, like RFC 9457 examples do, and with "keyword" and
+// "keywordLocation" members. Pointer is a percent-encoded URI fragment
+// of the invalid value.
+func (p Problem) Encode(e *jx.Encoder) {
+	e.ObjStart()
+	if p.Type != "" {
+		e.FieldStart("type")
+		e.Str(p.Type)
+	}
+	e.FieldStart("title")
+	e.Str(p.Title)
+	e.FieldStart("status")
+	e.Int(p.Status)
+	if p.Detail != "" {
+		e.FieldStart("detail")
+		e.Str(p.Detail)
+	}
+	if p.Instance != "" {
+		e.FieldStart("instance")
+		e.Str(p.Instance)
+	}
+	if len(p.Errors) > 0 {
+		e.FieldStart("errors")
+		e.ArrStart()
+		for _, err := range p.Errors {
+			e.ObjStart()
+			e.FieldStart("detail")
+			e.Str(err.Message)
+			e.FieldStart("pointer")
+			e.Str(pointerFragment(err.InstanceLocation))
+			if err.Keyword != "" {
+				e.FieldStart("keyword")
+				e.Str(err.Keyword)
+			}
+			e.FieldStart("keywordLocation")
+			e.Str(err.KeywordLocation)
+			e.ObjEnd()
+		}
+		e.ArrEnd()
+	}
+	e.ObjEnd()
+}
+
+// pointerFragment returns URI fragment representation of JSON Pointer,
+// defined by RFC 6901.
+func pointerFragment(ptr string) string {
+	u := url.URL{Fragment: ptr}
+	return "#" + u.EscapedFragment()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	var e jx.Encoder
+	p.Encode(&e)
+	return e.Bytes(), nil
+}
+
+// WriteProblem writes problem as "application/problem+json" response.
+func WriteProblem(w http.ResponseWriter, _ *http.Request, p Problem) {
+	var e jx.Encoder
+	p.Encode(&e)
+
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/problem+json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(e.Bytes())
+}
+
+// MiddlewareOptions is a Middleware options.
+type MiddlewareOptions struct {
+	// MaxBodySize limits size of validated request body.
+	//
+	// Defaults to DefaultMaxBodySize.
+	MaxBodySize int64
+	// All enables collecting of all validation errors, like ValidateAll
+	// does.
+	All bool
+	// ValidateResponse enables validation of response bodies.
+	//
+	// Responses are buffered until handler returns, so it is intended
+	// for development and testing. Invalid response is replaced by
+	// "500 Internal Server Error" problem.
+	ValidateResponse bool
+	// ErrorHandler writes problem response.
+	//
+	// Defaults to WriteProblem.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, p Problem)
+}
+
+// BodySchemas are schemas of request and response bodies of a route.
+type BodySchemas struct {
+	// Request is a schema of request body.
+	//
+	// If nil, request body is validated by schema of its content type,
+	// if any.
+	Request *Schema
+	// Response is a schema of successful (2xx) response body.
+	//
+	// If nil, response body is validated by schema of its content type,
+	// if any.
+	Response *Schema
+}
+
+// Middleware validates bodies of HTTP requests and responses.
+//
+// Schema of a body is selected by route pattern, then by its media
+// type. Invalid requests are rejected with RFC 9457 problem response
+// before the handler is called:
+//
+//   - "400 Bad Request", if body is not a valid JSON;
+//   - "413 Request Entity Too Large", if body is larger than the limit;
+//   - "415 Unsupported Media Type", if body is not JSON;
+//   - "422 Unprocessable Entity", if body is not valid against the
+//     schema, with validation errors in "errors" member.
+//
+// Request without "Content-Type" header is considered JSON. GET and HEAD
+// requests and requests without body are passed to the handler without
+// validation, so a pattern without method, like "/users/{id}", may be
+// used for all methods of the resource.
+//
+// Routes and media types must be added before the middleware starts
+// serving requests.
+type Middleware struct {
+	opts  MiddlewareOptions
+	mux   *http.ServeMux
+	types map[string]*Schema
+}
+
+// NewMiddleware creates new Middleware.
+func NewMiddleware(opts MiddlewareOptions) *Middleware {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = WriteProblem
+	}
+	return &Middleware{
+		opts:  opts,
+		mux:   http.NewServeMux(),
+		types: map[string]*Schema{},
+	}
+}
+
+// route is a marker handler, registered in the route mux.
+type route struct {
+	schemas BodySchemas
+}
+
+func (*route) ServeHTTP(http.ResponseWriter, *http.Request) {}
+
+// Handle sets schemas of the route with given pattern.
+//
+// Pattern syntax is the same as http.ServeMux uses, like
+// "POST /users/{id}". Like http.ServeMux, Handle panics if pattern is
+// invalid or conflicts with already added one.
+func (m *Middleware) Handle(pattern string, schemas BodySchemas) {
+	m.mux.Handle(pattern, &route{schemas: schemas})
+}
+
+// HandleContentType sets schema of bodies with given media type, like
+// "application/vnd.user+json".
+//
+// HandleContentType panics if media type is invalid.
+func (m *Middleware) HandleContentType(mediaType string, s *Schema) {
+	mt, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		panic(errors.Wrapf(err, "jsonschema: invalid media type %q", mediaType))
+	}
+	m.types[mt] = s
+}
+
+// Wrap returns handler, that validates bodies and calls next.
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var schemas BodySchemas
+		if h, _ := m.mux.Handler(r); h != nil {
+			if rt, ok := h.(*route); ok {
+				schemas = rt.schemas
+			}
+		}
+
+		mediaType, ok := bodyType(r.Header)
+		sch := schemas.Request
+		if sch == nil {
+			sch = m.types[mediaType]
+		}
+		if sch != nil && hasBody(r) {
+			if !ok || (mediaType != "" && !isJSONType(mediaType)) {
+				m.problem(w, r, http.StatusUnsupportedMediaType, "Request body must be JSON.", nil)
+				return
+			}
+			if !m.validateRequest(w, r, sch) {
+				return
+			}
+		}
+
+		if !m.opts.ValidateResponse {
+			next.ServeHTTP(w, r)
+			return
+		}
+		buf := &responseBuffer{header: http.Header{}}
+		next.ServeHTTP(buf, r)
+		m.writeResponse(w, r, schemas.Response, buf)
+	})
+}
+
+// hasBody reports whether request has a body to validate.
+func hasBody(r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return false
+	}
+	return r.ContentLength != 0 || (r.Body != nil && r.Body != http.NoBody)
+}
+
+// bodyType returns media type of the body and reports whether
+// "Content-Type" header is valid.
+//
+// If header is not set, bodyType returns empty string.
+func bodyType(h http.Header) (string, bool) {
+	ct := h.Get("Content-Type")
+	if ct == "" {
+		return "", true
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return "", false
+	}
+	return mediaType, true
+}
+
+// isJSONType reports whether media type is JSON or JSON-based.
+func isJSONType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func (m *Middleware) validate(s *Schema, data []byte) error {
+	if m.opts.All {
+		return s.ValidateAll(data)
+	}
+	return s.Validate(data)
+}
+
+// validateRequest validates request body and reports whether request
+// should be passed to the handler.
+//
+// Validated body is buffered and set back to the request.
+func (m *Middleware) validateRequest(w http.ResponseWriter, r *http.Request, s *Schema) bool {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, m.opts.MaxBodySize))
+	if err != nil {
+		if maxErr := new(http.MaxBytesError); errors.As(err, &maxErr) {
+			m.problem(w, r, http.StatusRequestEntityTooLarge, "Request body is too large.", nil)
+			return false
+		}
+		m.problem(w, r, http.StatusBadRequest, "Failed to read request body.", nil)
+		return false
+	}
+
+	if err := m.validate(s, data); err != nil {
+		errs, ok := asValidationErrors(err)
+		if !ok {
+			m.problem(w, r, http.StatusBadRequest, "Request body is not a valid JSON.", nil)
+			return false
+		}
+		m.problem(w, r, http.StatusUnprocessableEntity, "Request body is not valid against the schema.", errs)
+		return false
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	r.ContentLength = int64(len(data))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return true
+}
+
+// writeResponse validates buffered response and writes it.
+//
+// s is a schema of successful response, may be nil.
+func (m *Middleware) writeResponse(w http.ResponseWriter, r *http.Request, s *Schema, buf *responseBuffer) {
+	status := buf.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if r.Method != http.MethodHead && status != http.StatusNoContent && status != http.StatusNotModified {
+		mediaType, ok := bodyType(buf.header)
+		if s == nil || status < 200 || status > 299 {
+			s = m.types[mediaType]
+		}
+		// Like request, response without "Content-Type" header is
+		// considered JSON.
+		if s != nil && ok && (mediaType == "" || isJSONType(mediaType)) {
+			if err := m.validate(s, buf.body.Bytes()); err != nil {
+				detail := "Response body is not valid against the schema."
+				errs, ok := asValidationErrors(err)
+				if !ok {
+					detail = "Response body is not a valid JSON."
+				}
+				m.problem(w, r, http.StatusInternalServerError, detail, errs)
+				return
+			}
+		}
+	}
+
+	h := w.Header()
+	for k, v := range buf.header {
+		h[k] = v
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.body.Bytes())
+}
+
+func (m *Middleware) problem(w http.ResponseWriter, r *http.Request, status int, detail string, errs ValidationErrors) {
+	m.opts.ErrorHandler(w, r, Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Errors: errs,
+	})
+}
+
+// responseBuffer is a http.ResponseWriter, that buffers the response.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
@@ -0,0 +1,244 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-faster/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	user, err := Parse([]byte(`{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"age": {"type": "integer", "minimum": 0}
+		}
+	}`))
+	require.NoError(t, err)
+	event, err := Parse([]byte(`{"type": "object", "required": ["kind"]}`))
+	require.NoError(t, err)
+
+	newHandler := func(opts MiddlewareOptions, h http.HandlerFunc) http.Handler {
+		m := NewMiddleware(opts)
+		m.Handle("POST /users", BodySchemas{Request: user, Response: user})
+		m.Handle("/users/{id}", BodySchemas{Request: user})
+		m.HandleContentType("application/vnd.event+json; charset=utf-8", event)
+		return m.Wrap(h)
+	}
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.Header().Set("X-Echo", "1")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(data)
+	}
+	do := func(h http.Handler, method, target, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Request", func(t *testing.T) {
+		h := newHandler(MiddlewareOptions{MaxBodySize: 64}, echo)
+
+		for i, tt := range []struct {
+			method      string
+			target      string
+			contentType string
+			body        string
+			status      int
+		}{
+			{"POST", "/users", "application/json", `{"name": "foo"}`, http.StatusCreated},
+			{"POST", "/users", "", `{"name": "foo"}`, http.StatusCreated},
+			{"POST", "/users", "application/merge-patch+json", `{"name": "foo"}`, http.StatusCreated},
+			{"POST", "/users", "application/json", `{"name": ""}`, http.StatusUnprocessableEntity},
+			{"POST", "/users", "application/json", `{"name": `, http.StatusBadRequest},
+			{"POST", "/users", "application/json", ``, http.StatusBadRequest},
+			{"POST", "/users", "text/plain", `{"name": "foo"}`, http.StatusUnsupportedMediaType},
+			{"POST", "/users", "invalid/", `{"name": "foo"}`, http.StatusUnsupportedMediaType},
+			{"POST", "/users", "application/json", `{"name": "` + strings.Repeat("a", 64) + `"}`, http.StatusRequestEntityTooLarge},
+			// Not matched by route.
+			{"GET", "/users", "application/json", `{"name": ""}`, http.StatusCreated},
+			{"POST", "/users/1/avatar", "text/plain", `{"name": ""}`, http.StatusCreated},
+			// Matched by pattern without method.
+			{"PUT", "/users/1", "application/json", `{"name": "foo"}`, http.StatusCreated},
+			{"PUT", "/users/1", "application/json", `{"name": ""}`, http.StatusUnprocessableEntity},
+			// Bodies of GET and HEAD requests are not validated.
+			{"GET", "/users/1", "", ``, http.StatusCreated},
+			{"HEAD", "/users/1", "", ``, http.StatusCreated},
+			{"DELETE", "/users/1", "application/json", `{}`, http.StatusUnprocessableEntity},
+			// Matched by content type.
+			{"PUT", "/events", "application/vnd.event+json", `{"kind": "foo"}`, http.StatusCreated},
+			{"PUT", "/events", "application/vnd.event+json", `{}`, http.StatusUnprocessableEntity},
+			{"PUT", "/events", "Application/Vnd.Event+JSON; charset=utf-8", `{}`, http.StatusUnprocessableEntity},
+		} {
+			rec := do(h, tt.method, tt.target, tt.contentType, tt.body)
+			require.Equal(t, tt.status, rec.Code, "test %d: %s", i, rec.Body)
+			if rec.Code == http.StatusCreated {
+				require.Equal(t, tt.body, rec.Body.String(), "test %d", i)
+				continue
+			}
+			require.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"), "test %d", i)
+		}
+	})
+	t.Run("NoBody", func(t *testing.T) {
+		h := newHandler(MiddlewareOptions{}, echo)
+
+		for _, method := range []string{"POST", "PUT", "PATCH", "DELETE"} {
+			req := httptest.NewRequest(method, "/users/1", http.NoBody)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusCreated, rec.Code, method)
+
+			// Empty body is validated, if it is set.
+			rec = do(h, method, "/users/1", "", ``)
+			require.Equal(t, http.StatusBadRequest, rec.Code, method)
+		}
+	})
+	t.Run("Problem", func(t *testing.T) {
+		h := newHandler(MiddlewareOptions{All: true}, echo)
+
+		rec := do(h, "POST", "/users", "application/json", `{"name": "", "age": -1}`)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		require.JSONEq(t, `{
+			"title": "Unprocessable Entity",
+			"status": 422,
+			"detail": "Request body is not valid against the schema.",
+			"errors": [
+				{
+					"detail": "length 0 is smaller than 1",
+					"pointer": "#/name",
+					"keyword": "minLength",
+					"keywordLocation": "/properties/name/minLength"
+				},
+				{
+					"detail": "value -1 is smaller than 0",
+					"pointer": "#/age",
+					"keyword": "minimum",
+					"keywordLocation": "/properties/age/minimum"
+				}
+			]
+		}`, rec.Body.String())
+	})
+	t.Run("Pointer", func(t *testing.T) {
+		m := NewMiddleware(MiddlewareOptions{})
+		m.Handle("POST /", BodySchemas{Request: errors.Must(Parse([]byte(`{
+			"additionalProperties": {"type": "string"}
+		}`)))})
+		h := m.Wrap(http.HandlerFunc(echo))
+
+		rec := do(h, "POST", "/", "application/json", `{"a b%\"c/d": 1}`)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		var p struct {
+			Errors []struct {
+				Pointer string `json:"pointer"`
+			} `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+		require.Len(t, p.Errors, 1)
+		require.Equal(t, `#/a%20b%25%22c~1d`, p.Errors[0].Pointer)
+
+		u, err := url.Parse(p.Errors[0].Pointer)
+		require.NoError(t, err)
+		require.Equal(t, `/a b%"c~1d`, u.Fragment)
+	})
+	t.Run("ErrorHandler", func(t *testing.T) {
+		h := newHandler(MiddlewareOptions{
+			ErrorHandler: func(w http.ResponseWriter, r *http.Request, p Problem) {
+				p.Type = "https://example.com/problems/validation"
+				p.Instance = r.URL.Path
+				WriteProblem(w, r, p)
+			},
+		}, echo)
+
+		rec := do(h, "POST", "/users", "application/json", `{}`)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		require.JSONEq(t, `{
+			"type": "https://example.com/problems/validation",
+			"title": "Unprocessable Entity",
+			"status": 422,
+			"detail": "Request body is not valid against the schema.",
+			"instance": "/users",
+			"errors": [
+				{
+					"detail": "required property \"name\" is missing",
+					"pointer": "#",
+					"keyword": "required",
+					"keywordLocation": "/required"
+				}
+			]
+		}`, rec.Body.String())
+	})
+	t.Run("Response", func(t *testing.T) {
+		var response struct {
+			status      int
+			contentType string
+			body        string
+		}
+		h := newHandler(MiddlewareOptions{ValidateResponse: true}, func(w http.ResponseWriter, r *http.Request) {
+			if response.contentType != "" {
+				w.Header().Set("Content-Type", response.contentType)
+			}
+			w.WriteHeader(response.status)
+			_, _ = io.WriteString(w, response.body)
+		})
+
+		for i, tt := range []struct {
+			target      string
+			status      int
+			contentType string
+			body        string
+			valid       bool
+		}{
+			{"/users", http.StatusOK, "application/json", `{"name": "foo"}`, true},
+			{"/users", http.StatusOK, "application/json", `{"name": ""}`, false},
+			{"/users", http.StatusOK, "application/json", `{"name": `, false},
+			{"/users", http.StatusNoContent, "", ``, true},
+			// Schema of route is not applied to non-JSON responses.
+			{"/users", http.StatusOK, "text/plain", `foo`, true},
+			{"/users", http.StatusCreated, "application/octet-stream", "\x00", true},
+			// Schema of route is not applied to unsuccessful responses.
+			{"/users", http.StatusBadRequest, "application/problem+json", `{"title": "Bad Request"}`, true},
+			{"/users", http.StatusBadRequest, "application/vnd.event+json", `{}`, false},
+			// Schema of content type.
+			{"/events", http.StatusOK, "application/vnd.event+json", `{"kind": "foo"}`, true},
+			{"/events", http.StatusOK, "application/vnd.event+json", `{}`, false},
+			{"/events", http.StatusOK, "text/plain", `foo`, true},
+		} {
+			response.status, response.contentType, response.body = tt.status, tt.contentType, tt.body
+
+			rec := do(h, "POST", tt.target, "application/json", `{"name": "foo", "kind": "foo"}`)
+			if !tt.valid {
+				require.Equal(t, http.StatusInternalServerError, rec.Code, "test %d", i)
+				require.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"), "test %d", i)
+				continue
+			}
+			require.Equal(t, tt.status, rec.Code, "test %d: %s", i, rec.Body)
+			require.Equal(t, tt.contentType, rec.Header().Get("Content-Type"), "test %d", i)
+			require.Equal(t, tt.body, rec.Body.String(), "test %d", i)
+		}
+	})
+	t.Run("InvalidMediaType", func(t *testing.T) {
+		m := NewMiddleware(MiddlewareOptions{})
+		require.Panics(t, func() {
+			m.HandleContentType("invalid/", user)
+		})
+	})
+}
@@ -0,0 +1,291 @@
+package jsonschema
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-faster/errors"
+)
+
+// DefaultMaxSize is a default limit of document size, loaded by
+// HTTPResolver.
+const DefaultMaxSize = 8 << 20
+
+// DenyReason is a reason of denied fetch.
+type DenyReason int
+
+const (
+	// DeniedScheme means URI scheme is not allowed.
+	DeniedScheme DenyReason = iota + 1
+	// DeniedHost means host is not allowed.
+	DeniedHost
+	// DeniedAddress means host resolves to private, loopback or other
+	// non-public address.
+	DeniedAddress
+	// DeniedRedirect means server responded with too many redirects.
+	DeniedRedirect
+	// DeniedSize means response is too large.
+	DeniedSize
+)
+
+// String implements fmt.Stringer.
+func (r DenyReason) String() string {
+	switch r {
+	case DeniedScheme:
+		return "scheme"
+	case DeniedHost:
+		return "host"
+	case DeniedAddress:
+		return "address"
+	case DeniedRedirect:
+		return "redirect"
+	case DeniedSize:
+		return "size"
+	default:
+		return "unknown"
+	}
+}
+
+// DeniedError is returned by HTTPResolver, if fetch is denied.
+type DeniedError struct {
+	// URL is the denied URL.
+	URL string
+	// Reason is a reason of denial.
+	Reason DenyReason
+	// Addr is the denied address, set if Reason is DeniedAddress.
+	Addr string
+	// Limit is the exceeded limit, set if Reason is DeniedRedirect or
+	// DeniedSize.
+	Limit int64
+}
+
+// Error implements error.
+func (e *DeniedError) Error() string {
+	var msg string
+	switch e.Reason {
+	case DeniedScheme:
+		msg = "scheme is not allowed"
+	case DeniedHost:
+		msg = "host is not allowed"
+	case DeniedAddress:
+		msg = fmt.Sprintf("address %s is not allowed", e.Addr)
+	case DeniedRedirect:
+		msg = fmt.Sprintf("too many redirects (limit %d)", e.Limit)
+	case DeniedSize:
+		msg = fmt.Sprintf("response is larger than %d bytes", e.Limit)
+	default:
+		msg = e.Reason.String()
+	}
+	return fmt.Sprintf("fetch %q denied: %s", e.URL, msg)
+}
+
+// HTTPOptions is a HTTPResolver options.
+type HTTPOptions struct {
+	// AllowedSchemes is a list of allowed URI schemes.
+	//
+	// Defaults to "https".
+	AllowedSchemes []string
+	// AllowedHosts is a list of allowed hosts. Host starting with a dot
+	// allows all subdomains, e.g. ".example.com".
+	//
+	// Empty list allows any host.
+	AllowedHosts []string
+	// AllowPrivate allows loopback, private, link-local and other
+	// non-public addresses.
+	AllowPrivate bool
+	// MaxSize limits size of the response body.
+	//
+	// Defaults to DefaultMaxSize.
+	MaxSize int64
+	// MaxRedirects limits number of followed redirects.
+	//
+	// Zero means redirects are not followed.
+	MaxRedirects int
+	// Timeout limits time of a single fetch.
+	//
+	// Zero means no timeout.
+	Timeout time.Duration
+}
+
+var _ RemoteResolver = (*HTTPResolver)(nil)
+
+// HTTPResolver is RemoteResolver, that loads documents over HTTP and is
+// suitable for untrusted schemas.
+//
+// Unlike Remote, HTTPResolver checks every requested and redirected URL
+// against allowlists, checks every dialed address and limits response
+// size. Proxy settings from environment are ignored, since the proxy
+// address would be checked instead of the target address.
+type HTTPResolver struct {
+	opts   HTTPOptions
+	client *http.Client
+}
+
+// NewHTTPResolver creates new HTTPResolver.
+func NewHTTPResolver(opts HTTPOptions) *HTTPResolver {
+	if len(opts.AllowedSchemes) == 0 {
+		opts.AllowedSchemes = []string{"https"}
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMaxSize
+	}
+
+	r := &HTTPResolver{opts: opts}
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   r.control,
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	r.client = &http.Client{
+		Transport:     transport,
+		CheckRedirect: r.checkRedirect,
+		Timeout:       opts.Timeout,
+	}
+	return r
+}
+
+// checkURL checks URL against allowlists.
+func (r *HTTPResolver) checkURL(u *url.URL) error {
+	if !slices.Contains(r.opts.AllowedSchemes, strings.ToLower(u.Scheme)) {
+		return &DeniedError{URL: u.String(), Reason: DeniedScheme}
+	}
+	if hosts := r.opts.AllowedHosts; len(hosts) > 0 && !matchHost(hosts, u.Hostname()) {
+		return &DeniedError{URL: u.String(), Reason: DeniedHost}
+	}
+	return nil
+}
+
+// matchHost reports whether host matches any of given hosts.
+func matchHost(hosts []string, host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, h := range hosts {
+		h = strings.ToLower(h)
+		if h == host {
+			return true
+		}
+		if strings.HasPrefix(h, ".") && strings.HasSuffix(host, h) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *HTTPResolver) checkRedirect(req *http.Request, via []*http.Request) error {
+	if limit := r.opts.MaxRedirects; len(via) > limit {
+		return &DeniedError{
+			URL:    via[0].URL.String(),
+			Reason: DeniedRedirect,
+			Limit:  int64(limit),
+		}
+	}
+	return r.checkURL(req.URL)
+}
+
+// control checks dialed address.
+func (r *HTTPResolver) control(_, address string, _ syscall.RawConn) error {
+	if r.opts.AllowPrivate {
+		return nil
+	}
+	addr, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return errors.Wrap(err, "parse address")
+	}
+	if ip := addr.Addr().Unmap(); !isPublicAddr(ip) {
+		return &DeniedError{Reason: DeniedAddress, Addr: ip.String()}
+	}
+	return nil
+}
+
+// nonPublicPrefixes are special-purpose ranges, which are not covered by
+// netip.Addr methods, see RFC 6890.
+var nonPublicPrefixes = []netip.Prefix{
+	// "This network", see RFC 791.
+	netip.MustParsePrefix("0.0.0.0/8"),
+	// Carrier-grade NAT, see RFC 6598.
+	netip.MustParsePrefix("100.64.0.0/10"),
+	// Benchmarking, see RFC 2544.
+	netip.MustParsePrefix("198.18.0.0/15"),
+	// Reserved, see RFC 1112.
+	netip.MustParsePrefix("240.0.0.0/4"),
+	// NAT64 well-known prefix, embeds IPv4 address, see RFC 6052.
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// isPublicAddr reports whether ip is a public unicast address.
+func isPublicAddr(ip netip.Addr) bool {
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// Resolve implements RemoteResolver.
+func (r *HTTPResolver) Resolve(ctx context.Context, loc string) ([]byte, error) {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse location")
+	}
+	if err := r.checkURL(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create request")
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		var denied *DeniedError
+		if errors.As(err, &denied) {
+			if denied.URL == "" {
+				denied.URL = u.String()
+			}
+			return nil, denied
+		}
+		return nil, errors.Wrap(err, "do")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if code := resp.StatusCode; code < 200 || code >= 300 {
+		text := http.StatusText(code)
+		return nil, errors.Errorf("bad HTTP code %d (%s)", code, text)
+	}
+
+	limit := r.opts.MaxSize
+	tooLarge := &DeniedError{URL: u.String(), Reason: DeniedSize, Limit: limit}
+	if resp.ContentLength > limit {
+		return nil, tooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "read data")
+	}
+	if int64(len(data)) > limit {
+		return nil, tooLarge
+	}
+	return data, nil
+}
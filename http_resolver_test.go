@@ -0,0 +1,151 @@
+package jsonschema
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/go-faster/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPResolver(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/schema.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"type": "string"}`))
+	})
+	mux.HandleFunc("/large.json", func(w http.ResponseWriter, r *http.Request) {
+		// Write body without Content-Length.
+		for i := 0; i < 10; i++ {
+			_, _ = w.Write([]byte(strings.Repeat(" ", 100)))
+			w.(http.Flusher).Flush()
+		}
+		_, _ = w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/redirect/", func(w http.ResponseWriter, r *http.Request) {
+		switch rest := strings.TrimPrefix(r.URL.Path, "/redirect/"); rest {
+		case "0":
+			http.Redirect(w, r, "/schema.json", http.StatusFound)
+		case "external":
+			http.Redirect(w, r, "https://example.com/schema.json", http.StatusFound)
+		default:
+			http.Redirect(w, r, "/redirect/0", http.StatusFound)
+		}
+	})
+	mux.HandleFunc("/notfound.json", http.NotFound)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	local := HTTPOptions{
+		AllowedSchemes: []string{"http"},
+		AllowPrivate:   true,
+	}
+	resolve := func(opts HTTPOptions, p string) ([]byte, error) {
+		return NewHTTPResolver(opts).Resolve(ctx, srv.URL+p)
+	}
+	denied := func(t *testing.T, err error, reason DenyReason) {
+		t.Helper()
+		var e *DeniedError
+		require.Truef(t, errors.As(err, &e), "unexpected error %v", err)
+		require.Equal(t, reason, e.Reason, e.Error())
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		a := require.New(t)
+
+		data, err := resolve(local, "/schema.json")
+		a.NoError(err)
+		a.Equal(`{"type": "string"}`, string(data))
+
+		_, err = resolve(local, "/notfound.json")
+		a.Error(err)
+	})
+	t.Run("Scheme", func(t *testing.T) {
+		_, err := resolve(HTTPOptions{AllowPrivate: true}, "/schema.json")
+		denied(t, err, DeniedScheme)
+	})
+	t.Run("Host", func(t *testing.T) {
+		opts := local
+		opts.AllowedHosts = []string{"example.com", ".example.org"}
+		_, err := resolve(opts, "/schema.json")
+		denied(t, err, DeniedHost)
+
+		opts.AllowedHosts = []string{"127.0.0.1"}
+		_, err = resolve(opts, "/schema.json")
+		require.NoError(t, err)
+	})
+	t.Run("Address", func(t *testing.T) {
+		opts := local
+		opts.AllowPrivate = false
+		_, err := resolve(opts, "/schema.json")
+		denied(t, err, DeniedAddress)
+	})
+	t.Run("Size", func(t *testing.T) {
+		opts := local
+		opts.MaxSize = 512
+		_, err := resolve(opts, "/large.json")
+		denied(t, err, DeniedSize)
+
+		opts.MaxSize = 16
+		_, err = resolve(opts, "/schema.json")
+		denied(t, err, DeniedSize)
+	})
+	t.Run("Redirect", func(t *testing.T) {
+		a := require.New(t)
+
+		_, err := resolve(local, "/redirect/0")
+		denied(t, err, DeniedRedirect)
+
+		opts := local
+		opts.MaxRedirects = 1
+		_, err = resolve(opts, "/redirect/0")
+		a.NoError(err)
+		_, err = resolve(opts, "/redirect/1")
+		denied(t, err, DeniedRedirect)
+		_, err = resolve(opts, "/redirect/external")
+		denied(t, err, DeniedScheme)
+
+		opts.MaxRedirects = 2
+		_, err = resolve(opts, "/redirect/1")
+		a.NoError(err)
+	})
+	t.Run("Compile", func(t *testing.T) {
+		_, err := ParseWithOptions([]byte(`{"$ref": "`+srv.URL+`/schema.json"}`), Options{
+			Remote: NewHTTPResolver(HTTPOptions{AllowedSchemes: []string{"http"}}),
+		})
+		denied(t, err, DeniedAddress)
+	})
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"8.8.8.8":              true,
+		"2001:4860:4860::8888": true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"fd00::1":              false,
+		"fe80::1":              false,
+		"224.0.0.1":            false,
+		"0.1.2.3":              false,
+		"198.18.0.1":           false,
+		"198.19.255.255":       false,
+		"198.20.0.1":           true,
+		"240.0.0.1":            false,
+		"255.255.255.254":      false,
+		"64:ff9b::a9fe:a9fe":   false,
+		"64:ff9b::808:808":     false,
+		"2606:4700::1111":      true,
+	} {
+		require.Equal(t, public, isPublicAddr(netip.MustParseAddr(addr)), addr)
+	}
+}
@@ -23,7 +23,13 @@ func splitFunc(s string, sep byte, cb func(s string) error) error {
 	return cb(s)
 }
 
-func find(u *url.URL, buf []byte, validate bool) (*url.URL, []byte, error) {
+func find(dr *draft, u *url.URL, buf []byte, validate bool) (*url.URL, []byte, error) {
+	return findFrom(dr, u, buf, validate, false)
+}
+
+// findFrom is like find, but if identified is set, u is already based on
+// the identifier of the buf schema.
+func findFrom(dr *draft, u *url.URL, buf []byte, validate, identified bool) (*url.URL, []byte, error) {
 	d := jx.GetDecoder()
 	defer jx.PutDecoder(d)
 
@@ -42,8 +48,10 @@ func find(u *url.URL, buf []byte, validate bool) (*url.URL, []byte, error) {
 	// Cut first /.
 	ptr = ptr[1:]
 
-	err := splitFunc(ptr, '/', func(part string) (err error) {
-		part = unescape(part)
+	// rel is a pointer relative to the last found "id".
+	rel := ""
+	err := splitFunc(ptr, '/', func(rawPart string) (err error) {
+		part := unescape(rawPart)
 		var (
 			result []byte
 			ok     bool
@@ -51,10 +59,18 @@ func find(u *url.URL, buf []byte, validate bool) (*url.URL, []byte, error) {
 		d.ResetBytes(buf)
 		switch tt := d.Next(); tt {
 		case jx.Object:
-			r, err := findKey(u, d, part)
+			r, err := findKey(dr, u, d, part)
 			if err != nil {
 				return errors.Wrapf(err, "find key %q", part)
 			}
+			if identified {
+				// Identifier is already applied.
+				r.u, identified = u, false
+			}
+			if r.u != u && !sameResource(r.u, u) {
+				// Object has identifier, so base URI is changed.
+				rel = ""
+			}
 			u, result, ok = r.u, r.result, r.ok
 		case jx.Array:
 			result, ok, err = findIdx(d, part)
@@ -69,9 +85,19 @@ func find(u *url.URL, buf []byte, validate bool) (*url.URL, []byte, error) {
 		}
 
 		buf = result
+		rel += "/" + rawPart
 		return err
 	})
-	return u, buf, err
+	if err != nil {
+		return nil, nil, err
+	}
+	if u != nil && u.Fragment != rel {
+		// Make copy.
+		loc := *u
+		loc.Fragment = rel
+		u = &loc
+	}
+	return u, buf, nil
 }
 
 func findIdx(d *jx.Decoder, part string) (result []byte, ok bool, _ error) {
@@ -110,17 +136,22 @@ type findKeyResult struct {
 	ok     bool
 }
 
-func findKey(base *url.URL, d *jx.Decoder, part string) (r findKeyResult, _ error) {
+// sameResource reports whether given URLs are pointing to the same resource.
+func sameResource(a, b *url.URL) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return stripFragment(a) == stripFragment(b)
+}
+
+func findKey(dr *draft, base *url.URL, d *jx.Decoder, part string) (r findKeyResult, _ error) {
 	iter, err := d.ObjIter()
 	if err != nil {
 		return r, err
 	}
 
+	var ref bool
 	for iter.Next() {
-		if r.ok && r.u != nil {
-			// We found "id" and needed key, return.
-			break
-		}
 		switch key := iter.Key(); string(key) {
 		case part:
 			raw, err := d.Raw()
@@ -129,14 +160,18 @@ func findKey(base *url.URL, d *jx.Decoder, part string) (r findKeyResult, _ erro
 			}
 			r.result = raw
 			r.ok = true
-		case "id":
+		case "$ref":
+			ref = true
+			if err := d.Skip(); err != nil {
+				return r, err
+			}
+		case dr.id:
 			if d.Next() != jx.String {
 				if err := d.Skip(); err != nil {
 					return r, err
 				}
 				continue
 			}
-			// TODO(tdakkota): get id field name from draft struct
 			id, err := d.Str()
 			if err != nil {
 				return r, errors.Wrapf(err, "parse %q", key)
@@ -158,7 +193,8 @@ func findKey(base *url.URL, d *jx.Decoder, part string) (r findKeyResult, _ erro
 			}
 		}
 	}
-	if r.u == nil {
+	if r.u == nil || (ref && dr.refOverrides()) {
+		// "$ref" overrides all sibling keywords, including identifier.
 		r.u = base
 	}
 	return r, iter.Err()
@@ -169,8 +205,20 @@ var (
 		"~1", "/",
 		"~0", "~",
 	)
+	escapeReplacer = strings.NewReplacer(
+		"~", "~0",
+		"/", "~1",
+	)
 )
 
+func escape(part string) string {
+	// Replacer always creates new string, check that escape is really necessary.
+	if !strings.ContainsAny(part, "~/") {
+		return part
+	}
+	return escapeReplacer.Replace(part)
+}
+
 func unescape(part string) string {
 	// Replacer always creates new string, check that unescape is really necessary.
 	if !strings.Contains(part, "~1") && !strings.Contains(part, "~0") {
@@ -178,3 +226,106 @@ func unescape(part string) string {
 	}
 	return unescapeReplacer.Replace(part)
 }
+
+// pointer is a JSON Pointer builder.
+//
+// Tokens are stored as is and escaped only by String, so locations of
+// valid values cost nothing but an append.
+type pointer struct {
+	tokens []pointerToken
+	// off disables tracking, if location is not needed.
+	off bool
+}
+
+// pointerToken is a reference token of pointer.
+//
+// Token is either str, bytes or array index idx, if idx is not negative.
+type pointerToken struct {
+	str   string
+	bytes []byte
+	idx   int
+}
+
+// len returns number of tokens.
+func (p *pointer) len() int {
+	return len(p.tokens)
+}
+
+// push appends reference token to the pointer and returns previous length.
+func (p *pointer) push(token string) int {
+	n := len(p.tokens)
+	if !p.off {
+		p.tokens = append(p.tokens, pointerToken{str: token, idx: -1})
+	}
+	return n
+}
+
+// pushBytes is like push, but accepts token as byte slice.
+//
+// Token is not copied, so it must not be modified until pop.
+func (p *pointer) pushBytes(token []byte) int {
+	n := len(p.tokens)
+	if !p.off {
+		p.tokens = append(p.tokens, pointerToken{bytes: token, idx: -1})
+	}
+	return n
+}
+
+// pushIndex appends array index to the pointer and returns previous length.
+func (p *pointer) pushIndex(idx int) int {
+	n := len(p.tokens)
+	if !p.off {
+		p.tokens = append(p.tokens, pointerToken{idx: idx})
+	}
+	return n
+}
+
+// pop truncates pointer to given length.
+func (p *pointer) pop(n int) {
+	if len(p.tokens) != n {
+		p.tokens = p.tokens[:n]
+	}
+}
+
+// reset empties the pointer, keeping its buffer.
+func (p *pointer) reset() {
+	// Drop references to token data.
+	clear(p.tokens[:cap(p.tokens)])
+	p.tokens = p.tokens[:0]
+	p.off = false
+}
+
+// String returns pointer as string.
+func (p pointer) String() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	var b []byte
+	for _, t := range p.tokens {
+		b = append(b, '/')
+		switch {
+		case t.idx >= 0:
+			b = strconv.AppendInt(b, int64(t.idx), 10)
+		case t.bytes != nil:
+			for _, c := range t.bytes {
+				b = appendEscaped(b, c)
+			}
+		default:
+			for i := 0; i < len(t.str); i++ {
+				b = appendEscaped(b, t.str[i])
+			}
+		}
+	}
+	return string(b)
+}
+
+func appendEscaped(b []byte, c byte) []byte {
+	switch c {
+	case '~':
+		return append(b, '~', '0')
+	case '/':
+		return append(b, '~', '1')
+	default:
+		return append(b, c)
+	}
+}
@@ -82,7 +82,7 @@ func TestSpecification(t *testing.T) {
 				base.Fragment, _ = url.PathUnescape(strings.TrimPrefix(tt.ptr, "#"))
 			}
 
-			_, got, err := find(base, specExample, true)
+			_, got, err := find(draft4, base, specExample, true)
 			if tt.wantErr {
 				a.Error(err)
 				return
@@ -128,7 +128,7 @@ func BenchmarkResolve(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, buf, err = find(ref, specExample, true)
+		_, buf, err = find(draft4, ref, specExample, true)
 	}
 
 	if err != nil {
@@ -163,7 +163,7 @@ func TestResolve(t *testing.T) {
 				Fragment: strings.TrimPrefix(tt.ptr, "#"),
 			}
 
-			_, got, err := find(base, []byte(tt.input), true)
+			_, got, err := find(draft4, base, []byte(tt.input), true)
 			if tt.wantErr {
 				a.Error(err)
 				return
@@ -217,7 +217,7 @@ func Test_findKey(t *testing.T) {
 		tt := tt
 		t.Run(fmt.Sprintf("Test%d", i+1), func(t *testing.T) {
 			a := require.New(t)
-			r, err := findKey(nil, jx.DecodeStr(tt.input), tt.part)
+			r, err := findKey(draft4, nil, jx.DecodeStr(tt.input), tt.part)
 			if tt.wantErr {
 				a.Error(err)
 				a.False(r.ok)
@@ -229,3 +229,21 @@ func Test_findKey(t *testing.T) {
 		})
 	}
 }
+
+func Test_pointer(t *testing.T) {
+	a := require.New(t)
+
+	var p pointer
+	n := p.push("properties")
+	p.push("a/b~c")
+	a.Equal("/properties/a~1b~0c", p.String())
+
+	m := p.pushBytes([]byte("items"))
+	p.pushIndex(10)
+	a.Equal("/properties/a~1b~0c/items/10", p.String())
+
+	p.pop(m)
+	a.Equal("/properties/a~1b~0c", p.String())
+	p.pop(n)
+	a.Equal("", p.String())
+}
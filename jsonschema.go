@@ -1,16 +1,154 @@
 package jsonschema
 
-import "encoding/json"
+import (
+	"context"
+
+	"github.com/go-faster/errors"
+)
+
+// Options is a JSON Schema compilation options.
+type Options struct {
+	// Draft forces JSON Schema draft to use, ignoring "$schema" keyword.
+	//
+	// By default, draft is detected from "$schema" keyword of the document.
+	Draft Draft
+	// DefaultDraft is a draft of documents without "$schema" keyword or
+	// with unknown meta-schema URI.
+	//
+	// Remote documents without "$schema" keyword inherit draft of the
+	// referencing document instead.
+	//
+	// Defaults to Draft4.
+	DefaultDraft Draft
+	// AssertFormat enables "format" keyword assertion.
+	//
+	// By default, "format" is an annotation and does not affect validation.
+	AssertFormat bool
+	// Formats is a set of custom formats.
+	//
+	// Custom format overrides built-in format with the same name.
+	// Like built-in formats, custom formats are asserted only if
+	// AssertFormat is set.
+	Formats map[string]FormatFunc
+	// UnknownFormat defines behavior on unknown format.
+	//
+	// Defaults to IgnoreUnknownFormat.
+	UnknownFormat UnknownFormat
+	// AssertContent enables "contentEncoding" and "contentMediaType"
+	// keywords assertion.
+	//
+	// By default, these keywords are annotations. Supported encodings are
+	// "base64", supported media types are "application/json".
+	AssertContent bool
+	// ValidateSchema enables validation of schema documents against
+	// meta-schema of their draft before compilation.
+	//
+	// If schema is invalid, Parse returns *SchemaError.
+	ValidateSchema bool
+	// Strict enables strict mode.
+	//
+	// Strict mode enables ValidateSchema.
+	Strict bool
+	// Remote resolves remote references.
+	//
+	// Defaults to Remote{}.
+	Remote RemoteResolver
+	// RegexpEngine compiles regular expressions of "pattern" and
+	// "patternProperties" keywords.
+	//
+	// Defaults to regexp.Compile.
+	RegexpEngine RegexpEngine
+	// MaxRefDepth limits depth of nested references resolution.
+	//
+	// Defaults to 1000.
+	MaxRefDepth int
+	// MaxRemotes limits number of documents, loaded by Remote.
+	//
+	// Zero means no limit.
+	MaxRemotes int
+	// Warn is called on compilation warnings, if set.
+	//
+	// loc is an absolute location of the schema keyword.
+	Warn func(loc, msg string)
+}
 
 // Parse parses given JSON and compiles JSON Schema validator.
 func Parse(data []byte) (*Schema, error) {
-	var raw RawSchema
-	if err := json.Unmarshal(data, &raw); err != nil {
-		return nil, err
+	return ParseWithOptions(data, Options{})
+}
+
+// ParseWithOptions parses given JSON and compiles JSON Schema validator
+// using given options.
+func ParseWithOptions(data []byte, opts Options) (*Schema, error) {
+	return NewCompiler(opts).Compile(data)
+}
+
+// ParseContext parses given JSON and compiles JSON Schema validator
+// using given options.
+//
+// Given context is passed to RemoteResolver, so it can be used to cancel
+// loading of remote references.
+func ParseContext(ctx context.Context, data []byte, opts Options) (*Schema, error) {
+	return NewCompiler(opts).CompileContext(ctx, data)
+}
+
+// draftOf returns draft of given document.
+func (o Options) draftOf(data []byte) (*draft, error) {
+	return o.draftOr(data, nil)
+}
+
+// draftOr is like draftOf, but returns inherited draft instead of
+// default one, if it is not nil.
+func (o Options) draftOr(data []byte, inherited *draft) (*draft, error) {
+	version := o.Draft
+	if version == 0 {
+		uri, err := schemaURI(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse $schema")
+		}
+		if d, ok := metaSchemaDraft(uri); ok {
+			version = d
+		}
 	}
-	doc, err := collectIDs(nil, data)
-	if err != nil {
-		return nil, err
+	if version == 0 {
+		if inherited != nil {
+			return inherited, nil
+		}
+		return o.defaultDraft()
 	}
-	return newCompiler(doc).Compile(raw)
+
+	dr, ok := drafts[version]
+	if !ok {
+		return nil, errors.Errorf("unsupported draft %d", version)
+	}
+	return dr, nil
+}
+
+// defaultDraft returns draft of documents without "$schema" keyword.
+func (o Options) defaultDraft() (*draft, error) {
+	version := o.DefaultDraft
+	if version == 0 {
+		return draft4, nil
+	}
+
+	dr, ok := drafts[version]
+	if !ok {
+		return nil, errors.Errorf("unsupported draft %d", version)
+	}
+	return dr, nil
 }
+
+func (o Options) validateSchema() bool {
+	return o.ValidateSchema || o.Strict
+}
+
+// Regexp is a compiled regular expression.
+//
+// *regexp.Regexp implements Regexp.
+type Regexp interface {
+	Match(b []byte) bool
+	String() string
+}
+
+// RegexpEngine compiles regular expressions.
+type RegexpEngine func(pattern string) (Regexp, error)
@@ -1,6 +1,8 @@
 package jsonschema
 
 import (
+	"bytes"
+	"context"
 	"embed"
 	"encoding/json"
 	"fmt"
@@ -8,6 +10,7 @@ import (
 	"path"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/go-faster/errors"
 	"github.com/stretchr/testify/require"
@@ -21,9 +24,68 @@ type testingT interface {
 var (
 	//go:embed _draft/draft4.json
 	draft4Raw []byte
-	draft4    = errors.Must(Parse(draft4Raw))
+	//go:embed _draft/draft6.json
+	draft6Raw []byte
+	//go:embed _draft/draft7.json
+	draft7Raw []byte
+	//go:embed _draft/draft2019-9.json
+	draft201909Raw []byte
+	//go:embed _draft/draft2020-12.json
+	draft202012Raw []byte
+
+	// suiteDrafts maps test suite directory to the draft.
+	suiteDrafts = map[string]suiteDraft{
+		"draft4": {Draft4, errors.Must(ParseWithOptions(draft4Raw, Options{Draft: Draft4}))},
+		"draft6": {Draft6, errors.Must(ParseWithOptions(draft6Raw, Options{Draft: Draft6}))},
+		"draft7": {Draft7, errors.Must(ParseWithOptions(draft7Raw, Options{Draft: Draft7}))},
+		"draft2019-09": {
+			Draft201909,
+			errors.Must(ParseWithOptions(draft201909Raw, Options{Draft: Draft201909})),
+		},
+		"draft2020-12": {
+			Draft202012,
+			errors.Must(ParseWithOptions(draft202012Raw, Options{Draft: Draft202012})),
+		},
+	}
 )
 
+// suiteSkip is a list of known gaps, it maps test name, relative to the
+// draft directory, to the reason.
+var suiteSkip = map[string]string{
+	// Custom meta-schemas are not supported, vocabularies of the draft are
+	// always enabled.
+	"vocabulary/Test1/Case3": "$vocabulary is not supported",
+	// Patterns are compiled by regexp package by default, which implements
+	// RE2 syntax, not ECMA-262 one. Use Options.RegexpEngine to change it.
+	"optional/ecmascript-regex/Test3":  "RE2 does not support \\c control escape",
+	"optional/ecmascript-regex/Test4":  "RE2 does not support \\c control escape",
+	"optional/ecmascript-regex/Test9":  "RE2 \\s matches ASCII whitespace only",
+	"optional/ecmascript-regex/Test10": "RE2 \\S matches non-ASCII whitespace",
+	"optional/unicode/Test1":           "RE2 \\w matches ASCII characters only",
+	"optional/unicode/Test3":           "RE2 \\d matches ASCII digits only",
+	"optional/unicode/Test4":           "RE2 \\w matches ASCII characters only",
+	"optional/unicode/Test6":           "RE2 \\d matches ASCII digits only",
+	// Internationalized hostname labels are checked by Unicode categories,
+	// IDNA2008 rules require tables not provided by standard library.
+	"optional/format/idn-hostname/Test1": "IDNA2008 rules are not implemented",
+}
+
+// skipReason returns reason to skip given suite test, if any.
+func skipReason(name string) (string, bool) {
+	// Trim test function and draft directory.
+	parts := strings.SplitN(name, "/", 3)
+	if len(parts) < 3 {
+		return "", false
+	}
+	reason, ok := suiteSkip[parts[2]]
+	return reason, ok
+}
+
+type suiteDraft struct {
+	draft Draft
+	meta  *Schema
+}
+
 func mustDir(t testingT, fsys embed.FS, p string) []fs.DirEntry {
 	entries, err := fsys.ReadDir(p)
 	require.NoError(t, err)
@@ -48,17 +110,25 @@ type Test struct {
 	Tests       []Case          `json:"tests"`
 }
 
-func runTests(t *testing.T, tests []Test) {
+func runTests(t *testing.T, draft suiteDraft, opts Options, tests []Test) {
 	for i, test := range tests {
 		test := test
 		t.Run(fmt.Sprintf("Test%d", i+1), func(t *testing.T) {
-			require.NoError(t, draft4.Validate(test.Schema))
+			if reason, ok := skipReason(t.Name()); ok {
+				t.Skip(reason)
+			}
+			require.NoError(t, draft.meta.Validate(test.Schema))
 
-			sch, err := Parse(test.Schema)
+			opts.Draft = draft.draft
+			opts.Remote = suiteRemote
+			sch, err := ParseWithOptions(test.Schema, opts)
 			require.NoError(t, err)
 			for i, cse := range test.Tests {
 				cse := cse
 				t.Run(fmt.Sprintf("Case%d", i+1), func(t *testing.T) {
+					if reason, ok := skipReason(t.Name()); ok {
+						t.Skip(reason)
+					}
 					a := require.New(t)
 
 					f := "Schema: %s,\nData: %s,\nDescription: %s"
@@ -67,10 +137,34 @@ func runTests(t *testing.T, tests []Test) {
 						cse.Data,
 						cse.Description,
 					}
-					if err := sch.Validate(cse.Data); cse.Valid {
-						a.NoErrorf(err, f, args...)
-					} else {
-						a.Errorf(err, f, args...)
+					for _, validate := range []func([]byte) error{
+						sch.Validate,
+						sch.ValidateAll,
+						func(data []byte) error {
+							val, err := decodeValue(data)
+							if err != nil {
+								return err
+							}
+							return sch.ValidateValue(val)
+						},
+						func(data []byte) error {
+							return sch.ValidateReader(iotest.OneByteReader(bytes.NewReader(data)))
+						},
+						func(data []byte) error {
+							var val, expected any
+							if err := Unmarshal(sch, data, &val); err != nil {
+								return err
+							}
+							a.NoError(json.Unmarshal(data, &expected))
+							a.Equalf(expected, val, f, args...)
+							return nil
+						},
+					} {
+						if err := validate(cse.Data); cse.Valid {
+							a.NoErrorf(err, f, args...)
+						} else {
+							a.Errorf(err, f, args...)
+						}
 					}
 				})
 			}
@@ -78,35 +172,55 @@ func runTests(t *testing.T, tests []Test) {
 	}
 }
 
-func runSuite(t *testing.T, suite embed.FS, suiteRoot string) {
-	drafts := mustDir(t, suite, suiteRoot)
+// setOptions returns compilation options for given test set directory or
+// file path.
+//
+// Optional format and content tests assert these keywords, which are
+// annotations by default.
+func setOptions(opts Options, p string) Options {
+	switch {
+	case strings.HasSuffix(p, "/optional/format"):
+		opts.AssertFormat = true
+	case strings.HasSuffix(p, "/optional/content.json"):
+		opts.AssertContent = true
+	}
+	return opts
+}
 
-	for _, draft := range drafts {
-		draftName := draft.Name()
-		t.Run(draftName, func(t *testing.T) {
-			draftPath := path.Join(suiteRoot, draftName)
-			sets := mustDir(t, suite, draftPath)
+// runSet runs test sets of given directory and its subdirectories.
+func runSet(t *testing.T, suite embed.FS, draft suiteDraft, opts Options, dir string) {
+	for _, set := range mustDir(t, suite, dir) {
+		setName := set.Name()
+		setPath := path.Join(dir, setName)
+		opts := setOptions(opts, setPath)
+		if set.IsDir() {
+			t.Run(setName, func(t *testing.T) {
+				runSet(t, suite, draft, opts, setPath)
+			})
+			continue
+		}
+		t.Run(strings.TrimSuffix(setName, ".json"), func(t *testing.T) {
+			data := mustFile(t, suite, setPath)
 
-			skipSet := map[string]struct{}{
-				"format": {},
-			}
+			var tests []Test
+			require.NoError(t, json.Unmarshal(data, &tests))
 
-			for _, set := range sets {
-				setName := set.Name()
-				testName := strings.TrimSuffix(setName, ".json")
-				t.Run(strings.TrimSuffix(setName, ".json"), func(t *testing.T) {
-					if _, ok := skipSet[testName]; ok {
-						t.Skipf("%s not supported yet", testName)
-						return
-					}
-					data := mustFile(t, suite, path.Join(draftPath, setName))
+			runTests(t, draft, opts, tests)
+		})
+	}
+}
 
-					var tests []Test
-					require.NoError(t, json.Unmarshal(data, &tests))
+func runSuite(t *testing.T, suite embed.FS, suiteRoot string) {
+	for _, draft := range mustDir(t, suite, suiteRoot) {
+		if _, ok := suiteDrafts[draft.Name()]; !ok {
+			t.Fatalf("unknown draft directory %q", draft.Name())
+		}
+	}
 
-					runTests(t, tests)
-				})
-			}
+	for draftName, draft := range suiteDrafts {
+		draft := draft
+		t.Run(draftName, func(t *testing.T) {
+			runSet(t, suite, draft, Options{}, path.Join(suiteRoot, draftName))
 		})
 	}
 }
@@ -174,6 +288,9 @@ func TestParse(t *testing.T) {
 		{`{"$ref":":"}`, nil, true},
 		// Invalid "required".
 		{veryBad, nil, true},
+		// Boolean schemas are not allowed in Draft 4.
+		{`true`, nil, true},
+		{`{"properties":{"foo":false}}`, nil, true},
 		// Bad regex.
 		{`{"pattern":"\\"}`, nil, true},
 		{`{"patternProperties":{"\\":{}}}`, nil, true},
@@ -192,3 +309,134 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestParseWithOptions_Draft(t *testing.T) {
+	tests := []struct {
+		schema string
+		opts   Options
+		data   string
+		valid  bool
+	}{
+		// Draft 4 by default, "if" is unknown keyword.
+		{`{"if": {"type": "string"}, "then": {"minLength": 2}}`, Options{}, `"a"`, true},
+		{
+			`{"$schema": "http://json-schema.org/draft-07/schema#", "if": {"type": "string"}, "then": {"minLength": 2}}`,
+			Options{}, `"a"`, false,
+		},
+		{
+			`{"$schema": "https://json-schema.org/draft/2020-12/schema", "prefixItems": [{"type": "string"}]}`,
+			Options{}, `[1]`, false,
+		},
+		// "$id" is an identifier since Draft 6.
+		{
+			`{
+				"$schema": "http://json-schema.org/draft-06/schema#",
+				"$id": "http://example.com/root.json",
+				"properties": {"foo": {"$ref": "item.json"}},
+				"definitions": {"item": {"$id": "item.json", "type": "integer"}}
+			}`,
+			Options{}, `{"foo": "bar"}`, false,
+		},
+		// Default draft is used without "$schema".
+		{`{"const": 1}`, Options{DefaultDraft: Draft6}, `2`, false},
+		{`{"$schema": "http://example.com/custom", "const": 1}`, Options{DefaultDraft: Draft6}, `2`, false},
+		{`{"$schema": "http://json-schema.org/draft-04/schema#", "const": 1}`, Options{DefaultDraft: Draft6}, `2`, true},
+		// Forced draft overrides "$schema".
+		{
+			`{"$schema": "http://json-schema.org/draft-07/schema#", "if": {"type": "string"}, "then": {"minLength": 2}}`,
+			Options{Draft: Draft4}, `"a"`, true,
+		},
+	}
+	for i, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprintf("Test%d", i+1), func(t *testing.T) {
+			a := require.New(t)
+
+			sch, err := ParseWithOptions([]byte(tt.schema), tt.opts)
+			a.NoError(err)
+			if err := sch.Validate([]byte(tt.data)); tt.valid {
+				a.NoError(err)
+			} else {
+				a.Error(err)
+			}
+		})
+	}
+
+	_, err := ParseWithOptions([]byte(`{}`), Options{DefaultDraft: 5})
+	require.Error(t, err)
+}
+
+func TestParseWithOptions_ValidateSchema(t *testing.T) {
+	tests := []struct {
+		schema string
+		opts   Options
+		locs   []string
+	}{
+		{`{"type": "object"}`, Options{Strict: true}, nil},
+		{`{"properties": {"foo": {"required": []}}}`, Options{}, nil},
+		{`{"properties": {"foo": {"required": []}}}`, Options{Strict: true}, []string{"/properties/foo/required"}},
+		{
+			`{"properties": {"foo": {"required": []}}, "enum": [1, 1]}`,
+			Options{ValidateSchema: true},
+			[]string{"/properties/foo/required", "/enum"},
+		},
+		{
+			`{"$schema": "https://json-schema.org/draft/2020-12/schema", "$defs": {"foo": {"type": "bar"}}}`,
+			Options{Strict: true},
+			[]string{"/$defs/foo/type"},
+		},
+	}
+	for i, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprintf("Test%d", i+1), func(t *testing.T) {
+			a := require.New(t)
+
+			_, err := ParseWithOptions([]byte(tt.schema), tt.opts)
+			if len(tt.locs) == 0 {
+				a.NoError(err)
+				return
+			}
+
+			var e *SchemaError
+			a.ErrorAs(err, &e)
+			var locs []string
+			for _, err := range e.Errors {
+				locs = append(locs, err.InstanceLocation)
+			}
+			a.Equal(tt.locs, locs)
+		})
+	}
+}
+
+type ctxResolver struct{}
+
+type ctxKey struct{}
+
+func (ctxResolver) Resolve(ctx context.Context, loc string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if ctx.Value(ctxKey{}) == nil {
+		return nil, errors.New("context is not propagated")
+	}
+	return []byte(`{"type": "string"}`), nil
+}
+
+func TestParseContext(t *testing.T) {
+	a := require.New(t)
+	schema := []byte(`{"properties": {"foo": {"$ref": "https://example.com/string.json"}}}`)
+	opts := Options{Remote: ctxResolver{}}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, true)
+	s, err := ParseContext(ctx, schema, opts)
+	a.NoError(err)
+	a.Error(s.Validate([]byte(`{"foo": 1}`)))
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = ParseContext(ctx, schema, opts)
+	a.ErrorIs(err, context.Canceled)
+
+	_, err = NewCompiler(opts).CompileResourceContext(ctx, "https://example.com/string.json")
+	a.ErrorIs(err, context.Canceled)
+}
@@ -0,0 +1,103 @@
+package jsonschema
+
+import (
+	"embed"
+	"sync"
+
+	"github.com/go-faster/errors"
+)
+
+//go:embed _draft
+var metaSchemas embed.FS
+
+// metaSchemaFiles maps meta-schema URIs to embedded files.
+var metaSchemaFiles = map[string]string{
+	"http://json-schema.org/draft-04/schema": "_draft/draft4.json",
+	"http://json-schema.org/draft-06/schema": "_draft/draft6.json",
+	"http://json-schema.org/draft-07/schema": "_draft/draft7.json",
+
+	"https://json-schema.org/draft/2019-09/schema":          "_draft/draft2019-9.json",
+	"https://json-schema.org/draft/2019-09/meta/core":       "_draft/draft2019-09/meta/core.json",
+	"https://json-schema.org/draft/2019-09/meta/applicator": "_draft/draft2019-09/meta/applicator.json",
+	"https://json-schema.org/draft/2019-09/meta/validation": "_draft/draft2019-09/meta/validation.json",
+	"https://json-schema.org/draft/2019-09/meta/meta-data":  "_draft/draft2019-09/meta/meta-data.json",
+	"https://json-schema.org/draft/2019-09/meta/format":     "_draft/draft2019-09/meta/format.json",
+	"https://json-schema.org/draft/2019-09/meta/content":    "_draft/draft2019-09/meta/content.json",
+
+	"https://json-schema.org/draft/2020-12/schema":                 "_draft/draft2020-12.json",
+	"https://json-schema.org/draft/2020-12/meta/core":              "_draft/draft2020-12/meta/core.json",
+	"https://json-schema.org/draft/2020-12/meta/applicator":        "_draft/draft2020-12/meta/applicator.json",
+	"https://json-schema.org/draft/2020-12/meta/unevaluated":       "_draft/draft2020-12/meta/unevaluated.json",
+	"https://json-schema.org/draft/2020-12/meta/validation":        "_draft/draft2020-12/meta/validation.json",
+	"https://json-schema.org/draft/2020-12/meta/meta-data":         "_draft/draft2020-12/meta/meta-data.json",
+	"https://json-schema.org/draft/2020-12/meta/format-annotation": "_draft/draft2020-12/meta/format-annotation.json",
+	"https://json-schema.org/draft/2020-12/meta/content":           "_draft/draft2020-12/meta/content.json",
+}
+
+// metaSchema returns embedded meta-schema by given URI.
+//
+// URI must not contain fragment.
+func metaSchema(loc string) ([]byte, bool) {
+	name, ok := metaSchemaFiles[loc]
+	if !ok {
+		return nil, false
+	}
+	data, err := metaSchemas.ReadFile(name)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+var metaSchemaCache struct {
+	mux     sync.Mutex
+	schemas map[Draft]*Schema
+}
+
+// compileMetaSchema returns compiled meta-schema of given draft.
+func compileMetaSchema(dr *draft) (*Schema, error) {
+	metaSchemaCache.mux.Lock()
+	defer metaSchemaCache.mux.Unlock()
+
+	if s, ok := metaSchemaCache.schemas[dr.version]; ok {
+		return s, nil
+	}
+
+	data, ok := metaSchema(dr.meta)
+	if !ok {
+		return nil, errors.Errorf("meta-schema %q not found", dr.meta)
+	}
+	s, err := ParseWithOptions(data, Options{Draft: dr.version})
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse meta-schema %q", dr.meta)
+	}
+
+	if metaSchemaCache.schemas == nil {
+		metaSchemaCache.schemas = map[Draft]*Schema{}
+	}
+	metaSchemaCache.schemas[dr.version] = s
+	return s, nil
+}
+
+// validateSchema validates given schema document against meta-schema of
+// given draft.
+//
+// uri is an URI of the document, empty for the root document.
+func validateSchema(dr *draft, uri string, data []byte) error {
+	meta, err := compileMetaSchema(dr)
+	if err != nil {
+		return err
+	}
+	if err := meta.ValidateAll(data); err != nil {
+		errs, ok := asValidationErrors(err)
+		if !ok {
+			return err
+		}
+		return &SchemaError{
+			URI:        uri,
+			MetaSchema: dr.meta,
+			Errors:     errs,
+		}
+	}
+	return nil
+}
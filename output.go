@@ -0,0 +1,375 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/go-faster/errors"
+	"github.com/go-faster/jx"
+)
+
+// OutputFormat defines JSON Schema output format.
+//
+// See https://json-schema.org/draft/2019-09/json-schema-core.html#rfc.section.10.4.
+type OutputFormat int
+
+const (
+	// FlagOutput contains only validation result.
+	FlagOutput OutputFormat = iota
+	// BasicOutput contains flat list of errors.
+	BasicOutput
+	// DetailedOutput contains hierarchical structure of errors, that
+	// follows the schema structure.
+	DetailedOutput
+	// VerboseOutput contains hierarchical structure of results of every
+	// evaluated subschema, including successful ones with their
+	// annotations.
+	VerboseOutput
+)
+
+// String implements fmt.Stringer.
+func (f OutputFormat) String() string {
+	switch f {
+	case FlagOutput:
+		return "flag"
+	case BasicOutput:
+		return "basic"
+	case DetailedOutput:
+		return "detailed"
+	case VerboseOutput:
+		return "verbose"
+	default:
+		return "unknown"
+	}
+}
+
+// OutputUnit is a single node of validation output.
+type OutputUnit struct {
+	Valid bool
+	// KeywordLocation is a JSON Pointer to the evaluated keyword or
+	// subschema, relative to the root schema.
+	KeywordLocation string
+	// AbsoluteKeywordLocation is an absolute URI of the evaluated keyword
+	// or subschema. Empty, if schema has no base URI.
+	AbsoluteKeywordLocation string
+	// InstanceLocation is a JSON Pointer to the evaluated value.
+	InstanceLocation string
+	// Error is an error message of failed keyword.
+	Error string
+	// Annotation is a value of annotation keyword, like "title".
+	Annotation json.RawMessage
+	// Errors is a list of nested units of failed subschema.
+	Errors []OutputUnit
+	// Annotations is a list of nested units of successful subschema,
+	// including units of its annotation keywords.
+	Annotations []OutputUnit
+}
+
+// Encode encodes unit as JSON.
+func (u OutputUnit) Encode(e *jx.Encoder) {
+	e.ObjStart()
+	e.FieldStart("valid")
+	e.Bool(u.Valid)
+	e.FieldStart("keywordLocation")
+	e.Str(u.KeywordLocation)
+	if u.AbsoluteKeywordLocation != "" {
+		e.FieldStart("absoluteKeywordLocation")
+		e.Str(u.AbsoluteKeywordLocation)
+	}
+	e.FieldStart("instanceLocation")
+	e.Str(u.InstanceLocation)
+	if u.Error != "" {
+		e.FieldStart("error")
+		e.Str(u.Error)
+	}
+	if u.Annotation != nil {
+		e.FieldStart("annotation")
+		e.Raw(u.Annotation)
+	}
+	encodeUnits(e, "errors", u.Errors)
+	encodeUnits(e, "annotations", u.Annotations)
+	e.ObjEnd()
+}
+
+func encodeUnits(e *jx.Encoder, field string, units []OutputUnit) {
+	if len(units) == 0 {
+		return
+	}
+	e.FieldStart(field)
+	e.ArrStart()
+	for _, u := range units {
+		u.Encode(e)
+	}
+	e.ArrEnd()
+}
+
+// Output is a validation result in one of standard output formats.
+type Output struct {
+	Format OutputFormat
+	// Root is the root output unit.
+	//
+	// In FlagOutput format, only Valid field is set. In BasicOutput
+	// format, Errors is a flat list of keyword errors.
+	Root OutputUnit
+}
+
+// Valid reports whether instance is valid.
+func (o Output) Valid() bool {
+	return o.Root.Valid
+}
+
+// Encode encodes output as JSON.
+func (o Output) Encode(e *jx.Encoder) {
+	switch o.Format {
+	case FlagOutput:
+		e.ObjStart()
+		e.FieldStart("valid")
+		e.Bool(o.Root.Valid)
+		e.ObjEnd()
+	case BasicOutput:
+		e.ObjStart()
+		e.FieldStart("valid")
+		e.Bool(o.Root.Valid)
+		encodeUnits(e, "errors", o.Root.Errors)
+		e.ObjEnd()
+	default:
+		o.Root.Encode(e)
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o Output) MarshalJSON() ([]byte, error) {
+	var e jx.Encoder
+	o.Encode(&e)
+	return e.Bytes(), nil
+}
+
+// Evaluate validates given data and returns result in given output format.
+//
+// Returned error is non-nil only if data is not a valid JSON.
+func (s *Schema) Evaluate(data []byte, format OutputFormat) (Output, error) {
+	out := Output{Format: format}
+	if format == FlagOutput {
+		err := s.Validate(data)
+		if _, ok := asValidationErrors(err); err != nil && !ok {
+			return Output{}, err
+		}
+		out.Root.Valid = err == nil
+		return out, nil
+	}
+
+	d := jx.GetDecoder()
+	defer jx.PutDecoder(d)
+
+	d.ResetBytes(data)
+	if err := d.Validate(); err != nil {
+		return Output{}, errors.Wrap(err, "invalid json")
+	}
+
+	t := &outputTrace{}
+	v := &validator{all: true, trace: t}
+	if format == VerboseOutput {
+		// Verbose output includes annotations of successful subschemas.
+		v.annotations = new([]Annotation)
+	}
+	d.ResetBytes(data)
+	if err := s.validate(v, d); err != nil {
+		if _, ok := asValidationErrors(err); !ok {
+			return Output{}, err
+		}
+	}
+
+	root := t.root
+	switch format {
+	case BasicOutput:
+		out.Root = OutputUnit{Valid: root.valid}
+		if !root.valid {
+			root.flatten(&out.Root.Errors)
+		}
+	case DetailedOutput:
+		out.Root = root.detailed(true)
+	case VerboseOutput:
+		out.Root = root.verbose()
+	default:
+		return Output{}, errors.Errorf("unknown format %d", format)
+	}
+	return out, nil
+}
+
+// outputNode is a node of evaluation trace.
+//
+// Node is either a subschema evaluation or a keyword error.
+type outputNode struct {
+	parent *outputNode
+
+	keywordLocation         string
+	absoluteKeywordLocation string
+	instanceLocation        string
+	valid                   bool
+	children                []*outputNode
+	// annotations is a list of annotations, produced by the schema.
+	annotations []Annotation
+
+	// err is set, if node is a keyword error.
+	err *ValidationError
+	// discarded is set, if node result does not affect validation result,
+	// e.g. failed "anyOf" branch, when another branch succeeded.
+	discarded bool
+}
+
+func (n *outputNode) unit() OutputUnit {
+	if e := n.err; e != nil {
+		return OutputUnit{
+			Valid:                   false,
+			KeywordLocation:         e.KeywordLocation,
+			AbsoluteKeywordLocation: absoluteLocation(e.AbsoluteKeywordLocation),
+			InstanceLocation:        e.InstanceLocation,
+			Error:                   e.Message,
+		}
+	}
+	return OutputUnit{
+		Valid:                   n.valid,
+		KeywordLocation:         n.keywordLocation,
+		AbsoluteKeywordLocation: absoluteLocation(n.absoluteKeywordLocation),
+		InstanceLocation:        n.instanceLocation,
+	}
+}
+
+// flatten appends all keyword errors of failed subschemas to the list.
+func (n *outputNode) flatten(to *[]OutputUnit) {
+	for _, c := range n.children {
+		switch {
+		case c.discarded:
+		case c.err != nil:
+			*to = append(*to, c.unit())
+		case !c.valid:
+			c.flatten(to)
+		}
+	}
+}
+
+func (n *outputNode) detailed(root bool) OutputUnit {
+	var errs []OutputUnit
+	for _, c := range n.children {
+		switch {
+		case c.discarded:
+		case c.err != nil:
+			errs = append(errs, c.unit())
+		case !c.valid:
+			errs = append(errs, c.detailed(false))
+		}
+	}
+	if !root && len(errs) == 1 {
+		// Node with a single child is replaced by the child.
+		return errs[0]
+	}
+	u := n.unit()
+	u.Errors = errs
+	return u
+}
+
+func (n *outputNode) verbose() OutputUnit {
+	u := n.unit()
+	if n.err != nil {
+		return u
+	}
+	units := make([]OutputUnit, 0, len(n.children))
+	if n.valid {
+		for _, an := range n.annotations {
+			units = append(units, OutputUnit{
+				Valid:                   true,
+				KeywordLocation:         an.KeywordLocation,
+				AbsoluteKeywordLocation: absoluteLocation(an.AbsoluteKeywordLocation),
+				InstanceLocation:        an.InstanceLocation,
+				Annotation:              an.Value,
+			})
+		}
+	}
+	for _, c := range n.children {
+		units = append(units, c.verbose())
+	}
+	if len(units) == 0 {
+		return u
+	}
+	if n.valid {
+		u.Annotations = units
+	} else {
+		u.Errors = units
+	}
+	return u
+}
+
+// absoluteLocation returns given location, if it is an absolute URI.
+func absoluteLocation(loc string) string {
+	if strings.HasPrefix(loc, "#") {
+		// Schema has no base URI.
+		return ""
+	}
+	return loc
+}
+
+// outputTrace records evaluation of every subschema.
+type outputTrace struct {
+	root    *outputNode
+	current *outputNode
+}
+
+func (t *outputTrace) enter(v *validator, s *Schema) *outputNode {
+	n := &outputNode{
+		parent:                  t.current,
+		keywordLocation:         v.keyword.String(),
+		absoluteKeywordLocation: s.loc,
+		instanceLocation:        v.instance.String(),
+	}
+	if t.current != nil {
+		t.current.children = append(t.current.children, n)
+	} else {
+		t.root = n
+	}
+	t.current = n
+	return n
+}
+
+func (t *outputTrace) leave(n *outputNode, err error) {
+	n.valid = err == nil
+	t.current = n.parent
+}
+
+// annotate sets annotations, produced by the current schema.
+func (t *outputTrace) annotate(list []Annotation) {
+	if t.current == nil || len(list) == 0 {
+		return
+	}
+	// List is truncated and reused, if schema fails.
+	t.current.annotations = slices.Clone(list)
+}
+
+func (t *outputTrace) fail(e *ValidationError) {
+	if t.current == nil {
+		return
+	}
+	t.current.children = append(t.current.children, &outputNode{
+		parent: t.current,
+		err:    e,
+	})
+}
+
+// mark returns position of the next child node of the current node.
+func (t *outputTrace) mark() int {
+	if t == nil || t.current == nil {
+		return 0
+	}
+	return len(t.current.children)
+}
+
+// discard marks child nodes of the current node, starting from given
+// position, as not affecting validation result.
+func (t *outputTrace) discard(from int) {
+	if t == nil || t.current == nil {
+		return
+	}
+	for _, c := range t.current.children[from:] {
+		c.discarded = true
+	}
+}
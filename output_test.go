@@ -0,0 +1,90 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_Evaluate(t *testing.T) {
+	sch, err := Parse([]byte(`{
+	"properties": {
+		"port": {"type": "integer", "maximum": 65535, "title": "Port", "default": 8080},
+		"mode": {"anyOf": [{"enum": ["tcp", "udp"]}, {"type": "null"}]}
+	}
+}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		data   string
+		format OutputFormat
+		want   string
+	}{
+		{`{"port": 80}`, FlagOutput, `{"valid":true}`},
+		{`{"port": 80}`, BasicOutput, `{"valid":true}`},
+		{`{"port": 80.5}`, FlagOutput, `{"valid":false}`},
+		{
+			`{"port": 80.5, "mode": "sctp"}`,
+			BasicOutput,
+			`{"valid":false,"errors":[` +
+				`{"valid":false,"keywordLocation":"/properties/port/type","instanceLocation":"/port","error":"number is not allowed"},` +
+				`{"valid":false,"keywordLocation":"/properties/mode/anyOf/0/enum","instanceLocation":"/mode","error":"value \"sctp\" is not present in enum"},` +
+				`{"valid":false,"keywordLocation":"/properties/mode/anyOf/1/type","instanceLocation":"/mode","error":"string is not allowed"},` +
+				`{"valid":false,"keywordLocation":"/properties/mode/anyOf","instanceLocation":"/mode","error":"must match at least once"}` +
+				`]}`,
+		},
+		{
+			`{"port": 80.5, "mode": "tcp"}`,
+			BasicOutput,
+			`{"valid":false,"errors":[` +
+				`{"valid":false,"keywordLocation":"/properties/port/type","instanceLocation":"/port","error":"number is not allowed"}` +
+				`]}`,
+		},
+		{
+			`{"port": 65536, "mode": "sctp"}`,
+			DetailedOutput,
+			`{"valid":false,"keywordLocation":"","instanceLocation":"","errors":[` +
+				`{"valid":false,"keywordLocation":"/properties/port/maximum","instanceLocation":"/port","error":"value 65536 is bigger than 65535"},` +
+				`{"valid":false,"keywordLocation":"/properties/mode","instanceLocation":"/mode","errors":[` +
+				`{"valid":false,"keywordLocation":"/properties/mode/anyOf/0/enum","instanceLocation":"/mode","error":"value \"sctp\" is not present in enum"},` +
+				`{"valid":false,"keywordLocation":"/properties/mode/anyOf/1/type","instanceLocation":"/mode","error":"string is not allowed"},` +
+				`{"valid":false,"keywordLocation":"/properties/mode/anyOf","instanceLocation":"/mode","error":"must match at least once"}` +
+				`]}` +
+				`]}`,
+		},
+		{
+			`{"port": 80}`,
+			VerboseOutput,
+			`{"valid":true,"keywordLocation":"","instanceLocation":"","annotations":[` +
+				`{"valid":true,"keywordLocation":"/properties/port","instanceLocation":"/port","annotations":[` +
+				`{"valid":true,"keywordLocation":"/properties/port/title","instanceLocation":"/port","annotation":"Port"},` +
+				`{"valid":true,"keywordLocation":"/properties/port/default","instanceLocation":"/port","annotation":8080}` +
+				`]}` +
+				`]}`,
+		},
+		{
+			`{"port": 80.5}`,
+			VerboseOutput,
+			`{"valid":false,"keywordLocation":"","instanceLocation":"","errors":[` +
+				`{"valid":false,"keywordLocation":"/properties/port","instanceLocation":"/port","errors":[` +
+				`{"valid":false,"keywordLocation":"/properties/port/type","instanceLocation":"/port","error":"number is not allowed"}` +
+				`]}` +
+				`]}`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.format.String(), func(t *testing.T) {
+			a := require.New(t)
+
+			out, err := sch.Evaluate([]byte(tt.data), tt.format)
+			a.NoError(err)
+			data, err := out.MarshalJSON()
+			a.NoError(err)
+			a.JSONEq(tt.want, string(data))
+		})
+	}
+
+	_, err = sch.Evaluate([]byte(`{`), BasicOutput)
+	require.Error(t, err)
+}
@@ -0,0 +1,539 @@
+package jsonschema
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+
+	"github.com/go-faster/errors"
+)
+
+// parser holds state of a single schema compilation.
+type parser struct {
+	// ctx is passed to remote resolver.
+	ctx    context.Context
+	doc    *document
+	remote RemoteResolver
+	opts   Options
+
+	remotes  map[string]*document
+	refcache map[string]*Schema
+	// fetched is a number of documents, loaded by remote resolver.
+	fetched int
+}
+
+// newParser creates new parser.
+//
+// resources is a set of pre-loaded documents.
+func newParser(ctx context.Context, root *document, opts Options, resources map[string]*document) *parser {
+	var remote RemoteResolver = Remote{}
+	if r := opts.Remote; r != nil {
+		remote = r
+	}
+	remotes := make(map[string]*document, len(resources)+2)
+	for loc, doc := range resources {
+		remotes[loc] = doc
+	}
+	remotes[""] = root
+	if root.id != nil {
+		r := stripFragment(root.id)
+		remotes[r.String()] = root
+	}
+	return &parser{
+		ctx:      ctx,
+		doc:      root,
+		remote:   remote,
+		opts:     opts,
+		remotes:  remotes,
+		refcache: map[string]*Schema{},
+	}
+}
+
+// Compile compiles given RawSchema and returns compiled Schema.
+//
+// Do not modify RawSchema fields, Schema will reference them.
+func (p *parser) Compile(schema RawSchema) (*Schema, error) {
+	return p.compile(schema, newResolveCtx(p.doc.draft, p.doc.id))
+}
+
+func (p *parser) compile(schema RawSchema, ctx *resolveCtx) (_ *Schema, err error) {
+	return p.compile1(schema, ctx, func(s *Schema) {})
+}
+
+func (p *parser) compile1(schema RawSchema, ctx *resolveCtx, save func(s *Schema)) (_ *Schema, err error) {
+	dr := ctx.draft
+	if schema.Bool != nil && !dr.boolSchemas {
+		return nil, errors.Errorf("boolean schema is not allowed in %s", dr.version)
+	}
+	if ref := schema.Ref; ref != "" && dr.refOverrides() {
+		target, err := p.resolve(ref, ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "resolve %q", ref)
+		}
+		s := &Schema{
+			loc:         ctx.location(),
+			ref:         target,
+			refOverride: true,
+		}
+		save(s)
+		return s, nil
+	}
+	if id := dr.rawID(schema); id != "" && !ctx.identified {
+		idURL, err := ctx.parseURL(id)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse $id")
+		}
+		var ptr string
+		if ctx.parent != nil && stripFragment(idURL) == stripFragment(ctx.parent) {
+			// Location-independent identifier, schema is still a part of
+			// the same resource.
+			ptr = ctx.ptr
+		}
+		ctx = ctx.child(idURL, ptr)
+	}
+
+	s := &Schema{
+		loc:                  ctx.location(),
+		ref:                  nil,
+		resource:             ctx.resource(),
+		types:                typeSet(0).set(schema.Type),
+		format:               schema.Format,
+		never:                schema.Bool != nil && !*schema.Bool,
+		enum:                 schema.Enum,
+		enumMap:              make(map[string]struct{}, len(schema.Enum)),
+		constant:             schema.Const,
+		allOf:                nil,
+		anyOf:                nil,
+		oneOf:                nil,
+		not:                  nil,
+		minProperties:        parseMinMax(schema.MinProperties),
+		maxProperties:        parseMinMax(schema.MaxProperties),
+		required:             map[string]struct{}{},
+		properties:           map[string]*Schema{},
+		patternProperties:    nil,
+		additionalProperties: additionalProperties{},
+		dependentRequired:    nil,
+		dependentSchemas:     nil,
+		dependentKeywords:    false,
+		propertyNames:        nil,
+		minItems:             parseMinMax(schema.MinItems),
+		maxItems:             parseMinMax(schema.MaxItems),
+		uniqueItems:          schema.UniqueItems,
+		items:                items{},
+		additionalItems:      additionalItems{},
+		contains:             nil,
+		minContains:          parseMinMax(schema.MinContains),
+		maxContains:          parseMinMax(schema.MaxContains),
+		minimum:              nil,
+		exclusiveMinimum:     false,
+		maximum:              nil,
+		exclusiveMaximum:     false,
+		minimumExclusive:     nil,
+		maximumExclusive:     nil,
+		multipleOf:           nil,
+		minLength:            parseMinMax(schema.MinLength),
+		maxLength:            parseMinMax(schema.MaxLength),
+		pattern:              nil,
+		contentEncoding:      "",
+		contentMediaType:     "",
+		contentCheck:         false,
+		title:                schema.Title,
+		description:          schema.Description,
+		defaultValue:         schema.Default,
+		examples:             nil,
+		deprecated:           false,
+		readOnly:             false,
+		writeOnly:            false,
+	}
+	save(s)
+
+	if dr.version >= Draft201909 {
+		if dr.version >= Draft202012 {
+			// "$recursiveRef" is replaced by "$dynamicRef".
+			schema.RecursiveRef, schema.RecursiveAnchor = "", false
+			s.dynamicAnchor = schema.DynamicAnchor
+			s.containsEvaluates = true
+		} else {
+			schema.DynamicRef = ""
+		}
+		s.recursiveAnchor = schema.RecursiveAnchor
+		s.deprecated = schema.Deprecated
+		if s.resource {
+			if err := p.compileDynamicAnchors(s, ctx); err != nil {
+				return nil, errors.Wrap(err, "dynamic anchors")
+			}
+		}
+		for _, ref := range []struct {
+			name string
+			to   **Schema
+			ref  string
+		}{
+			{"$ref", &s.ref, schema.Ref},
+			{"$recursiveRef", &s.recursiveRef, schema.RecursiveRef},
+			{"$dynamicRef", &s.dynamicRef, schema.DynamicRef},
+		} {
+			if ref.ref == "" {
+				continue
+			}
+			*ref.to, err = p.resolve(ref.ref, ctx)
+			if err != nil {
+				return nil, errors.Wrapf(err, "resolve %s %q", ref.name, ref.ref)
+			}
+		}
+		if target := s.dynamicRef; target != nil {
+			// Reference is dynamic only if initially resolved schema
+			// has the same dynamic anchor.
+			u, err := ctx.parseURL(schema.DynamicRef)
+			if err != nil {
+				return nil, errors.Wrap(err, "parse $dynamicRef")
+			}
+			if name := u.Fragment; name != "" && name == target.dynamicAnchor {
+				s.dynamicRefAnchor = name
+			}
+		}
+	} else {
+		// Keywords, introduced in Draft 2019-09, are unknown keywords.
+		schema.DependentRequired = nil
+		schema.DependentSchemas = nil
+		schema.UnevaluatedProperties = nil
+		schema.UnevaluatedItems = nil
+		s.minContains, s.maxContains = -1, -1
+	}
+	if dr.version >= Draft6 {
+		s.examples = schema.Examples
+		s.integralFloats = true
+	} else {
+		// Keywords, introduced in later drafts, are unknown keywords.
+		s.constant = nil
+		schema.Contains = nil
+		schema.PropertyNames = nil
+	}
+	if dr.version < Draft7 || schema.If == nil {
+		// "then" and "else" are ignored without "if".
+		schema.If, schema.Then, schema.Else = nil, nil, nil
+	}
+	if dr.version >= Draft7 {
+		s.contentEncoding = schema.ContentEncoding
+		s.contentMediaType = schema.ContentMediaType
+		s.contentCheck = p.opts.AssertContent &&
+			(s.contentEncoding != "" || s.contentMediaType != "")
+		s.readOnly = schema.ReadOnly
+		s.writeOnly = schema.WriteOnly
+	}
+
+	if f := schema.Format; f != "" {
+		check, err := p.format(f, ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "format")
+		}
+		if p.opts.AssertFormat {
+			s.formatCheck = check
+		}
+	}
+
+	for _, value := range schema.Enum {
+		s.enumMap[string(value)] = struct{}{}
+	}
+
+	for _, field := range schema.Required {
+		// See https://datatracker.ietf.org/doc/html/draft-fge-json-schema-validation-00#section-5.4.3.
+		//
+		// Elements of this array MUST be strings, and MUST be unique.
+		if _, ok := s.required[field]; ok {
+			return nil, errors.Errorf(`"required" list must be unique, duplicate %q`, field)
+		}
+		s.required[field] = struct{}{}
+	}
+
+	for _, field := range schema.Properties {
+		s.properties[field.Name], err = p.compile(field.Schema, ctx.sub("properties", field.Name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "property %q", field.Name)
+		}
+		s.propertyOrder = append(s.propertyOrder, field.Name)
+	}
+
+	for _, field := range schema.PatternProperties {
+		if err := func() error {
+			pattern, err := p.regexp(field.Pattern)
+			if err != nil {
+				return err
+			}
+
+			item, err := p.compile(field.Schema, ctx.sub("patternProperties", field.Pattern))
+			if err != nil {
+				return err
+			}
+
+			s.patternProperties = append(s.patternProperties, patternProperty{
+				Regexp: pattern,
+				Schema: item,
+			})
+			return nil
+		}(); err != nil {
+			return nil, errors.Wrapf(err, "patternProperty %q", field.Pattern)
+		}
+	}
+
+	if dr.version >= Draft202012 {
+		// Since Draft 2020-12, "prefixItems" replaces array form of "items"
+		// and "items" replaces "additionalItems".
+		s.prefixItems = true
+		if err := p.compilePrefixItems(s, schema, ctx); err != nil {
+			return nil, err
+		}
+		schema.Items, schema.AdditionalItems = nil, nil
+	}
+	if it := schema.Items; it != nil {
+		s.items.Set = true
+		if it.Array {
+			s.items.Array, err = p.compileMany(it.Schemas, ctx.sub("items"))
+		} else {
+			s.items.Object, err = p.compile(it.Schema, ctx.sub("items"))
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "items")
+		}
+	}
+
+	if ap := schema.AdditionalProperties; ap != nil {
+		s.additionalProperties.Set = true
+		if val := ap.Bool; val != nil {
+			s.additionalProperties.Bool = *val
+		} else {
+			s.additionalProperties.Schema, err = p.compile(ap.Schema, ctx.sub("additionalProperties"))
+			if err != nil {
+				return nil, errors.Wrap(err, "additionalProperties")
+			}
+		}
+	}
+
+	{
+		// Since Draft 2019-09, "dependencies" is split into
+		// "dependentRequired" and "dependentSchemas".
+		keyword, required, schemas := "dependencies", schema.Dependencies.Required, schema.Dependencies.Schemas
+		if dr.version >= Draft201909 {
+			keyword, required, schemas = "dependentSchemas",
+				schema.DependentRequired.requiredMap(), schema.DependentSchemas.schemaMap()
+			s.dependentKeywords = true
+		}
+		if len(schemas) > 0 {
+			s.dependentSchemas = make(map[string]*Schema, len(schemas))
+			for field, schema := range schemas {
+				s.dependentSchemas[field], err = p.compile(schema, ctx.sub(keyword, field))
+				if err != nil {
+					return nil, errors.Wrapf(err, "dependent schema %q", field)
+				}
+			}
+		}
+		s.dependentRequired = required
+	}
+
+	for _, single := range []struct {
+		name   string
+		to     **Schema
+		schema *RawSchema
+	}{
+		{"contains", &s.contains, schema.Contains},
+		{"propertyNames", &s.propertyNames, schema.PropertyNames},
+		{"if", &s.ifSchema, schema.If},
+		{"then", &s.thenSchema, schema.Then},
+		{"else", &s.elseSchema, schema.Else},
+		{"unevaluatedProperties", &s.unevaluatedProperties, schema.UnevaluatedProperties},
+		{"unevaluatedItems", &s.unevaluatedItems, schema.UnevaluatedItems},
+	} {
+		if single.schema == nil {
+			continue
+		}
+		*single.to, err = p.compile(*single.schema, ctx.sub(single.name))
+		if err != nil {
+			return nil, errors.Wrap(err, single.name)
+		}
+	}
+
+	if ai := schema.AdditionalItems; ai != nil {
+		s.additionalItems.Set = true
+		if val := ai.Bool; val != nil {
+			s.additionalItems.Bool = *val
+		} else {
+			s.additionalItems.Schema, err = p.compile(ai.Schema, ctx.sub("additionalItems"))
+			if err != nil {
+				return nil, errors.Wrap(err, "additionalItems")
+			}
+		}
+	}
+
+	if pattern := schema.Pattern; len(pattern) > 0 {
+		s.pattern, err = p.regexp(pattern)
+		if err != nil {
+			return nil, errors.Wrap(err, "pattern")
+		}
+	}
+
+	// TODO: how does it affect performance?
+	for _, many := range []struct {
+		name    string
+		to      *[]*Schema
+		schemas []RawSchema
+	}{
+		{"allOf", &s.allOf, schema.AllOf},
+		{"anyOf", &s.anyOf, schema.AnyOf},
+		{"oneOf", &s.oneOf, schema.OneOf},
+	} {
+		*many.to, err = p.compileMany(many.schemas, ctx.sub(many.name))
+		if err != nil {
+			return nil, errors.Wrap(err, many.name)
+		}
+	}
+
+	if sch := schema.Not; sch != nil {
+		s.not, err = p.compile(*sch, ctx.sub("not"))
+		if err != nil {
+			return nil, errors.Wrap(err, "not")
+		}
+	}
+
+	var exclusiveMinimum, exclusiveMaximum Num
+	if dr.version >= Draft6 {
+		exclusiveMinimum = schema.ExclusiveMinimum.Number
+		exclusiveMaximum = schema.ExclusiveMaximum.Number
+	} else {
+		s.exclusiveMinimum = schema.ExclusiveMinimum.Bool
+		s.exclusiveMaximum = schema.ExclusiveMaximum.Bool
+	}
+	for _, v := range []struct {
+		name string
+		to   **number
+		num  Num
+	}{
+		{"minimum", &s.minimum, schema.Minimum},
+		{"maximum", &s.maximum, schema.Maximum},
+		{"exclusiveMinimum", &s.minimumExclusive, exclusiveMinimum},
+		{"exclusiveMaximum", &s.maximumExclusive, exclusiveMaximum},
+		{"multipleOf", &s.multipleOf, schema.MultipleOf},
+	} {
+		if len(v.num) == 0 {
+			// Value is not set.
+			continue
+		}
+		val := new(big.Rat)
+		// TODO: more efficient way?
+		if err := val.UnmarshalText(v.num); err != nil {
+			return nil, errors.Wrap(err, v.name)
+		}
+		*v.to = &number{
+			Rat: val,
+			Raw: v.num,
+		}
+	}
+
+	return s, nil
+}
+
+// compilePrefixItems compiles "prefixItems" and "items" of Draft 2020-12.
+func (p *parser) compilePrefixItems(s *Schema, schema RawSchema, ctx *resolveCtx) (err error) {
+	it := schema.Items
+	if it != nil && it.Array {
+		return errors.New("items: array form is not allowed, use prefixItems")
+	}
+
+	if len(schema.PrefixItems) == 0 {
+		if it != nil {
+			s.items.Set = true
+			s.items.Object, err = p.compile(it.Schema, ctx.sub("items"))
+			if err != nil {
+				return errors.Wrap(err, "items")
+			}
+		}
+		return nil
+	}
+
+	s.items.Set = true
+	s.items.Array, err = p.compileMany(schema.PrefixItems, ctx.sub("prefixItems"))
+	if err != nil {
+		return errors.Wrap(err, "prefixItems")
+	}
+	if it != nil {
+		s.additionalItems.Set = true
+		if val := it.Schema.Bool; val != nil {
+			s.additionalItems.Bool = *val
+		} else {
+			s.additionalItems.Schema, err = p.compile(it.Schema, ctx.sub("items"))
+			if err != nil {
+				return errors.Wrap(err, "items")
+			}
+		}
+	}
+	return nil
+}
+
+// compileDynamicAnchors compiles dynamic anchors of schema resource s.
+func (p *parser) compileDynamicAnchors(s *Schema, ctx *resolveCtx) error {
+	var loc string
+	if ctx.parent != nil {
+		u := stripFragment(ctx.parent)
+		loc = u.String()
+	}
+	for _, doc := range p.remotes {
+		for _, name := range doc.dynamicAnchors[loc] {
+			if _, ok := s.dynamicAnchors[name]; ok {
+				continue
+			}
+			target, err := p.resolve("#"+name, ctx)
+			if err != nil {
+				return errors.Wrapf(err, "resolve %q", name)
+			}
+			if s.dynamicAnchors == nil {
+				s.dynamicAnchors = map[string]*Schema{}
+			}
+			s.dynamicAnchors[name] = target
+		}
+	}
+	return nil
+}
+
+// regexp compiles given regular expression using configured engine.
+func (p *parser) regexp(pattern string) (Regexp, error) {
+	if engine := p.opts.RegexpEngine; engine != nil {
+		return engine(pattern)
+	}
+	return regexp.Compile(pattern)
+}
+
+// format returns checker of given format.
+//
+// Returns nil, if format is unknown and unknown formats are allowed.
+func (p *parser) format(name string, ctx *resolveCtx) (FormatFunc, error) {
+	if f, ok := p.opts.Formats[name]; ok && f != nil {
+		return f, nil
+	}
+	if f, ok := ctx.draft.formats[name]; ok {
+		return f, nil
+	}
+
+	switch p.opts.UnknownFormat {
+	case WarnUnknownFormat:
+		if warn := p.opts.Warn; warn != nil {
+			warn(ctx.location()+"/format", fmt.Sprintf("unknown format %q", name))
+		}
+	case RejectUnknownFormat:
+		return nil, errors.Errorf("unknown format %q", name)
+	}
+	return nil, nil
+}
+
+func (p *parser) compileMany(schemas []RawSchema, ctx *resolveCtx) ([]*Schema, error) {
+	result := make([]*Schema, 0, len(schemas))
+	for i, schema := range schemas {
+		s, err := p.compile(schema, ctx.sub(strconv.Itoa(i)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "[%d]", i)
+		}
+
+		result = append(result, s)
+	}
+
+	return result, nil
+}
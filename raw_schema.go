@@ -1,7 +1,11 @@
 package jsonschema
 
 import (
+	"bytes"
 	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
 
 	"github.com/go-faster/errors"
 	"github.com/go-faster/jx"
@@ -32,6 +36,16 @@ func (n *Num) UnmarshalJSON(data []byte) error {
 // SchemaType represents JSON Schema type list.
 type SchemaType []string
 
+// MarshalJSON implements json.Marshaler.
+//
+// Single type is encoded as a string.
+func (r SchemaType) MarshalJSON() ([]byte, error) {
+	if len(r) == 1 {
+		return json.Marshal(r[0])
+	}
+	return json.Marshal([]string(r))
+}
+
 func (r *SchemaType) UnmarshalJSON(data []byte) error {
 	parseSingle := func(d *jx.Decoder) (string, error) {
 		val, err := d.StrBytes()
@@ -81,18 +95,74 @@ func (r *SchemaType) UnmarshalJSON(data []byte) error {
 	}
 }
 
+// Exclusive is JSON Schema exclusiveMinimum/exclusiveMaximum description.
+//
+// Draft 4 defines it as boolean modifier of minimum/maximum, since Draft 6
+// it is a number.
+type Exclusive struct {
+	Bool   bool
+	Number Num
+}
+
+// IsZero reports whether value is not set.
+func (r Exclusive) IsZero() bool {
+	return !r.Bool && len(r.Number) == 0
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r Exclusive) MarshalJSON() ([]byte, error) {
+	if len(r.Number) > 0 {
+		return r.Number.MarshalJSON()
+	}
+	return json.Marshal(r.Bool)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *Exclusive) UnmarshalJSON(data []byte) error {
+	d := jx.DecodeBytes(data)
+	switch tt := d.Next(); tt {
+	case jx.Bool:
+		val, err := d.Bool()
+		if err != nil {
+			return err
+		}
+		r.Bool = val
+		return nil
+	case jx.Number:
+		return r.Number.UnmarshalJSON(data)
+	default:
+		return errors.Errorf("unexpected type %s", tt.String())
+	}
+}
+
 // RawSchema is unparsed JSON Schema.
 type RawSchema struct {
-	ID     string            `json:"id,omitempty"` // TODO(tdakkota): get id field name from draft struct
-	Ref    string            `json:"$ref,omitempty"`
-	Type   SchemaType        `json:"type,omitempty"`
-	Format string            `json:"format,omitempty"`
-	Enum   []json.RawMessage `json:"enum,omitempty"`
+	// Bool is set if schema is a boolean schema.
+	//
+	// Boolean schemas are allowed since Draft 6.
+	Bool *bool `json:"-"`
+
+	Schema          string            `json:"$schema,omitempty"`
+	ID              string            `json:"id,omitempty"`  // Draft 4 identifier.
+	DollarID        string            `json:"$id,omitempty"` // Identifier since Draft 6.
+	Anchor          string            `json:"$anchor,omitempty"`
+	Ref             string            `json:"$ref,omitempty"`
+	RecursiveRef    string            `json:"$recursiveRef,omitempty"`
+	RecursiveAnchor bool              `json:"$recursiveAnchor,omitempty"`
+	DynamicRef      string            `json:"$dynamicRef,omitempty"`
+	DynamicAnchor   string            `json:"$dynamicAnchor,omitempty"`
+	Type            SchemaType        `json:"type,omitempty"`
+	Format          string            `json:"format,omitempty"`
+	Enum            []json.RawMessage `json:"enum,omitempty"`
+	Const           json.RawMessage   `json:"const,omitempty"`
 
 	AllOf []RawSchema `json:"allOf,omitempty"`
 	AnyOf []RawSchema `json:"anyOf,omitempty"`
 	OneOf []RawSchema `json:"oneOf,omitempty"`
 	Not   *RawSchema  `json:"not,omitempty"`
+	If    *RawSchema  `json:"if,omitempty"`
+	Then  *RawSchema  `json:"then,omitempty"`
+	Else  *RawSchema  `json:"else,omitempty"`
 
 	MinProperties        *uint64               `json:"minProperties,omitempty"`
 	MaxProperties        *uint64               `json:"maxProperties,omitempty"`
@@ -100,23 +170,239 @@ type RawSchema struct {
 	Properties           RawProperties         `json:"properties,omitempty"`
 	PatternProperties    RawPatternProperties  `json:"patternProperties,omitempty"`
 	AdditionalProperties *AdditionalProperties `json:"additionalProperties,omitempty"`
-	Dependencies         Dependencies          `json:"dependencies,omitempty"`
+	Dependencies         Dependencies          `json:"dependencies,omitzero"`
+	DependentRequired    RawDependentRequired  `json:"dependentRequired,omitempty"`
+	DependentSchemas     RawProperties         `json:"dependentSchemas,omitempty"`
+	PropertyNames        *RawSchema            `json:"propertyNames,omitempty"`
+
+	UnevaluatedProperties *RawSchema `json:"unevaluatedProperties,omitempty"`
 
 	MinItems        *uint64          `json:"minItems,omitempty"`
 	MaxItems        *uint64          `json:"maxItems,omitempty"`
 	UniqueItems     bool             `json:"uniqueItems,omitempty"`
+	PrefixItems     []RawSchema      `json:"prefixItems,omitempty"`
 	Items           *Items           `json:"items,omitempty"`
 	AdditionalItems *AdditionalItems `json:"additionalItems,omitempty"`
+	Contains        *RawSchema       `json:"contains,omitempty"`
+	MinContains     *uint64          `json:"minContains,omitempty"`
+	MaxContains     *uint64          `json:"maxContains,omitempty"`
+
+	UnevaluatedItems *RawSchema `json:"unevaluatedItems,omitempty"`
+
+	Minimum          Num       `json:"minimum,omitempty"`
+	ExclusiveMinimum Exclusive `json:"exclusiveMinimum,omitzero"`
+	Maximum          Num       `json:"maximum,omitempty"`
+	ExclusiveMaximum Exclusive `json:"exclusiveMaximum,omitzero"`
+	MultipleOf       Num       `json:"multipleOf,omitempty"`
+
+	MaxLength        *uint64 `json:"maxLength,omitempty"`
+	MinLength        *uint64 `json:"minLength,omitempty"`
+	Pattern          string  `json:"pattern,omitempty"`
+	ContentEncoding  string  `json:"contentEncoding,omitempty"`
+	ContentMediaType string  `json:"contentMediaType,omitempty"`
+
+	Definitions RawProperties `json:"definitions,omitempty"`
+	Defs        RawProperties `json:"$defs,omitempty"`
+
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Default     json.RawMessage   `json:"default,omitempty"`
+	Examples    []json.RawMessage `json:"examples,omitempty"`
+	Deprecated  bool              `json:"deprecated,omitempty"`
+	ReadOnly    bool              `json:"readOnly,omitempty"`
+	WriteOnly   bool              `json:"writeOnly,omitempty"`
+
+	// Extras is a list of unknown keywords, like "$comment" or vendor
+	// extensions ("x-*"), in definition order.
+	Extras Extras `json:"-"`
+	// keys is a list of schema keywords in definition order.
+	//
+	// Value is set only for known keywords with empty values, like
+	// "uniqueItems": false, omitted by json.Marshal.
+	keys Extras
+}
+
+type plainRawSchema RawSchema
+
+// rawSchemaKeywords maps keywords, defined by RawSchema fields, to
+// themselves, to reuse key strings.
+var rawSchemaKeywords = func() map[string]string {
+	t := reflect.TypeOf(plainRawSchema{})
+	r := make(map[string]string, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		r[name] = name
+	}
+	return r
+}()
+
+// MarshalJSON implements json.Marshaler.
+//
+// Keywords are encoded in definition order, if schema was unmarshaled,
+// followed by set keywords, that were not defined, and Extras. Values of
+// Extras are written as is, values of known keywords are re-encoded.
+func (r RawSchema) MarshalJSON() ([]byte, error) {
+	if r.Bool != nil {
+		return json.Marshal(*r.Bool)
+	}
+	data, err := json.Marshal(plainRawSchema(r))
+	if err != nil || (len(r.Extras) == 0 && len(r.keys) == 0) {
+		return data, err
+	}
 
-	Minimum          Num  `json:"minimum,omitempty"`
-	ExclusiveMinimum bool `json:"exclusiveMinimum,omitempty"`
-	Maximum          Num  `json:"maximum,omitempty"`
-	ExclusiveMaximum bool `json:"exclusiveMaximum,omitempty"`
-	MultipleOf       Num  `json:"multipleOf,omitempty"`
+	var fields Extras
+	if err := jx.DecodeBytes(data).ObjBytes(func(d *jx.Decoder, key []byte) error {
+		val, err := d.Raw()
+		if err != nil {
+			return err
+		}
+		fields = append(fields, Extra{Key: string(key), Value: json.RawMessage(val)})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
 
-	MaxLength *uint64 `json:"maxLength,omitempty"`
-	MinLength *uint64 `json:"minLength,omitempty"`
-	Pattern   string  `json:"pattern,omitempty"`
+	var (
+		e       jx.Encoder
+		written = make(map[string]struct{}, len(fields)+len(r.Extras))
+	)
+	write := func(list Extras, key string) {
+		if _, ok := written[key]; ok {
+			return
+		}
+		if val, ok := list.Get(key); ok {
+			written[key] = struct{}{}
+			e.FieldStart(key)
+			e.Raw(val)
+		}
+	}
+	e.ObjStart()
+	for _, k := range r.keys {
+		write(fields, k.Key)
+		write(r.Extras, k.Key)
+		if k.Value != nil {
+			write(r.keys, k.Key)
+		}
+	}
+	for _, f := range fields {
+		write(fields, f.Key)
+	}
+	for _, f := range r.Extras {
+		write(r.Extras, f.Key)
+	}
+	e.ObjEnd()
+	return e.Bytes(), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *RawSchema) UnmarshalJSON(data []byte) error {
+	d := jx.GetDecoder()
+	defer jx.PutDecoder(d)
+
+	d.ResetBytes(data)
+	if d.Next() == jx.Bool {
+		val, err := d.Bool()
+		if err != nil {
+			return err
+		}
+		*r = RawSchema{Bool: &val}
+		return nil
+	}
+	if err := json.Unmarshal(data, (*plainRawSchema)(r)); err != nil {
+		return err
+	}
+
+	// Most schemas have a few keywords.
+	r.Extras, r.keys = nil, make(Extras, 0, 8)
+	return d.ObjBytes(func(d *jx.Decoder, key []byte) error {
+		val, err := d.Raw()
+		if err != nil {
+			return err
+		}
+		// Values of known keywords are already decoded, keep only
+		// values, that json.Marshal omits.
+		if k, ok := rawSchemaKeywords[string(key)]; ok {
+			r.keys = append(r.keys, Extra{Key: k, Value: emptyValue(val)})
+			return nil
+		}
+		k := string(key)
+		r.keys = append(r.keys, Extra{Key: k})
+		r.Extras = append(r.Extras, Extra{Key: k, Value: copyRaw(val)})
+		return nil
+	})
+}
+
+var (
+	emptyFalse  = json.RawMessage("false")
+	emptyNull   = json.RawMessage("null")
+	emptyString = json.RawMessage(`""`)
+	emptyArray  = json.RawMessage("[]")
+	emptyObject = json.RawMessage("{}")
+)
+
+// emptyValue returns canonical form of given JSON, if it is false, null,
+// empty string, empty array or empty object, and nil otherwise.
+func emptyValue(raw []byte) json.RawMessage {
+	if len(raw) == 0 {
+		return nil
+	}
+	// Raw value is a single valid value, so array or object is empty if
+	// it has no tokens between brackets.
+	switch c := raw[0]; {
+	case c == 'f':
+		return emptyFalse
+	case c == 'n':
+		return emptyNull
+	case c == '"' && len(raw) == 2:
+		return emptyString
+	case c == '[' && len(bytes.TrimSpace(raw[1:len(raw)-1])) == 0:
+		return emptyArray
+	case c == '{' && len(bytes.TrimSpace(raw[1:len(raw)-1])) == 0:
+		return emptyObject
+	default:
+		return nil
+	}
+}
+
+// Extra is an unknown keyword of RawSchema.
+type Extra struct {
+	Key   string
+	Value json.RawMessage
+}
+
+// Extras is an ordered list of unknown keywords.
+type Extras []Extra
+
+// Get returns value of given keyword.
+func (e Extras) Get(key string) (json.RawMessage, bool) {
+	for _, f := range e {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set sets value of given keyword, adding it to the end of the list, if
+// keyword is not present.
+func (e *Extras) Set(key string, value json.RawMessage) {
+	for i, f := range *e {
+		if f.Key == key {
+			(*e)[i].Value = value
+			return
+		}
+	}
+	*e = append(*e, Extra{Key: key, Value: value})
+}
+
+// Delete removes given keyword.
+func (e *Extras) Delete(key string) {
+	*e = slices.DeleteFunc(*e, func(f Extra) bool {
+		return f.Key == key
+	})
 }
 
 // RawProperty is item of RawProperties.
@@ -188,7 +474,7 @@ func (p *Items) UnmarshalJSON(data []byte) error {
 	case jx.Array:
 		p.Array = true
 		return json.Unmarshal(data, &p.Schemas)
-	case jx.Object:
+	case jx.Object, jx.Bool:
 		return json.Unmarshal(data, &p.Schema)
 	default:
 		return errors.Errorf("unexpected type %s", tt.String())
@@ -248,51 +534,144 @@ func (r *RawPatternProperties) UnmarshalJSON(data []byte) error {
 	})
 }
 
+// RawRequiredDependency is item of RawDependentRequired.
+type RawRequiredDependency struct {
+	Name     string
+	Required []string
+}
+
+// RawDependentRequired is unparsed JSON Schema dependentRequired validator
+// description.
+type RawDependentRequired []RawRequiredDependency
+
+// MarshalJSON implements json.Marshaler.
+func (r RawDependentRequired) MarshalJSON() ([]byte, error) {
+	var e jx.Encoder
+	e.ObjStart()
+	for _, dep := range r {
+		e.FieldStart(dep.Name)
+		encodeStrings(&e, dep.Required)
+	}
+	e.ObjEnd()
+	return e.Bytes(), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *RawDependentRequired) UnmarshalJSON(data []byte) error {
+	d := jx.DecodeBytes(data)
+	return d.Obj(func(d *jx.Decoder, key string) error {
+		values, err := decodeStrings(d)
+		if err != nil {
+			return err
+		}
+		*r = append(*r, RawRequiredDependency{
+			Name:     key,
+			Required: values,
+		})
+		return nil
+	})
+}
+
+// requiredMap returns dependencies as map, or nil, if there are none.
+func (r RawDependentRequired) requiredMap() map[string][]string {
+	if len(r) == 0 {
+		return nil
+	}
+	m := make(map[string][]string, len(r))
+	for _, dep := range r {
+		m[dep.Name] = dep.Required
+	}
+	return m
+}
+
+// schemaMap returns properties as map, or nil, if there are none.
+func (p RawProperties) schemaMap() map[string]RawSchema {
+	if len(p) == 0 {
+		return nil
+	}
+	m := make(map[string]RawSchema, len(p))
+	for _, prop := range p {
+		m[prop.Name] = prop.Schema
+	}
+	return m
+}
+
 // Dependencies is unparsed JSON Schema dependencies validator description.
 type Dependencies struct {
 	Required map[string][]string
 	Schemas  map[string]RawSchema
+	// keys is a list of dependencies in definition order.
+	keys []string
+}
+
+// IsZero reports whether value is not set.
+func (r Dependencies) IsZero() bool {
+	return len(r.Required) == 0 && len(r.Schemas) == 0
 }
 
 // MarshalJSON implements json.Marshaler.
+//
+// Dependencies are encoded in definition order, if value was unmarshaled,
+// followed by set dependencies, that were not defined, in sorted order.
 func (r Dependencies) MarshalJSON() ([]byte, error) {
-	e := jx.GetWriter()
+	var (
+		e       jx.Encoder
+		written = make(map[string]struct{}, len(r.Required)+len(r.Schemas))
+	)
+	write := func(key string) error {
+		if _, ok := written[key]; ok {
+			return nil
+		}
+		if values, ok := r.Required[key]; ok {
+			written[key] = struct{}{}
+			e.FieldStart(key)
+			encodeStrings(&e, values)
+			return nil
+		}
+		if value, ok := r.Schemas[key]; ok {
+			written[key] = struct{}{}
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			e.FieldStart(key)
+			e.Raw(raw)
+		}
+		return nil
+	}
+
 	e.ObjStart()
-	for key, values := range r.Required {
-		e.FieldStart(key)
-		e.ArrStart()
-		for _, value := range values {
-			e.Str(value)
+	for _, key := range r.keys {
+		if err := write(key); err != nil {
+			return nil, err
 		}
-		e.ArrEnd()
 	}
-	for key, value := range r.Schemas {
-		e.FieldStart(key)
-		raw, err := json.Marshal(value)
-		if err != nil {
+	rest := make([]string, 0, len(r.Required)+len(r.Schemas))
+	for key := range r.Required {
+		rest = append(rest, key)
+	}
+	for key := range r.Schemas {
+		rest = append(rest, key)
+	}
+	slices.Sort(rest)
+	for _, key := range rest {
+		if err := write(key); err != nil {
 			return nil, err
 		}
-		e.Raw(raw)
 	}
 	e.ObjEnd()
-	return e.Buf, nil
+	return e.Bytes(), nil
 }
 
 // UnmarshalJSON implements json.Unmarshaler.
 func (r *Dependencies) UnmarshalJSON(data []byte) error {
 	d := jx.DecodeBytes(data)
 	return d.ObjBytes(func(d *jx.Decoder, key []byte) error {
+		r.keys = append(r.keys, string(key))
 		switch tt := d.Next(); tt {
 		case jx.Array:
-			var values []string
-			if err := d.Arr(func(d *jx.Decoder) error {
-				val, err := d.Str()
-				if err != nil {
-					return err
-				}
-				values = append(values, val)
-				return nil
-			}); err != nil {
+			values, err := decodeStrings(d)
+			if err != nil {
 				return err
 			}
 
@@ -301,7 +680,7 @@ func (r *Dependencies) UnmarshalJSON(data []byte) error {
 			}
 			r.Required[string(key)] = values
 			return nil
-		case jx.Object:
+		case jx.Object, jx.Bool:
 			raw, err := d.Raw()
 			if err != nil {
 				return err
@@ -323,6 +702,28 @@ func (r *Dependencies) UnmarshalJSON(data []byte) error {
 	})
 }
 
+func encodeStrings(e *jx.Encoder, values []string) {
+	e.ArrStart()
+	for _, value := range values {
+		e.Str(value)
+	}
+	e.ArrEnd()
+}
+
+func decodeStrings(d *jx.Decoder) (values []string, _ error) {
+	if err := d.Arr(func(d *jx.Decoder) error {
+		val, err := d.Str()
+		if err != nil {
+			return err
+		}
+		values = append(values, val)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
 type rawAdditional struct {
 	Bool   *bool
 	Schema RawSchema
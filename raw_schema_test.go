@@ -0,0 +1,141 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawSchema_Extras(t *testing.T) {
+	a := require.New(t)
+
+	const input = `{
+	"x-go-name": "Config",
+	"type": "object",
+	"$comment": "Service configuration",
+	"properties": {
+		"port": {"type": "integer", "x-order": 2, "x-meta": {"b": 1, "a": [1.0, 2e1]}},
+		"host": {"x-order": 1, "format": "hostname", "type": ["string", "null"]}
+	},
+	"required": ["port"],
+	"description": "",
+	"unknownKeyword": null
+}`
+	var raw RawSchema
+	a.NoError(json.Unmarshal([]byte(input), &raw))
+
+	comment, ok := raw.Extras.Get("$comment")
+	a.True(ok)
+	a.Equal(`"Service configuration"`, string(comment))
+	a.Equal([]string{"x-go-name", "$comment", "unknownKeyword"}, extraKeys(raw.Extras))
+	a.Equal([]string{"x-order", "x-meta"}, extraKeys(raw.Properties[0].Schema.Extras))
+
+	// Round-trip is exact up to whitespace.
+	data, err := json.Marshal(raw)
+	a.NoError(err)
+	a.Equal(compactJSON(t, input), string(data))
+
+	// Modified extras.
+	raw.Extras.Set("x-go-name", json.RawMessage(`"ServiceConfig"`))
+	raw.Extras.Set("x-new", json.RawMessage(`true`))
+	raw.Extras.Delete("unknownKeyword")
+	raw.Title = "Config"
+	data, err = json.Marshal(raw)
+	a.NoError(err)
+	a.Equal(compactJSON(t, `{
+	"x-go-name": "ServiceConfig",
+	"type": "object",
+	"$comment": "Service configuration",
+	"properties": {
+		"port": {"type": "integer", "x-order": 2, "x-meta": {"b": 1, "a": [1.0, 2e1]}},
+		"host": {"x-order": 1, "format": "hostname", "type": ["string", "null"]}
+	},
+	"required": ["port"],
+	"description": "",
+	"title": "Config",
+	"x-new": true
+}`), string(data))
+
+	// Extras of constructed schema.
+	data, err = json.Marshal(RawSchema{
+		Type:   SchemaType{"string"},
+		Extras: Extras{{Key: "x-foo", Value: json.RawMessage(`1`)}},
+	})
+	a.NoError(err)
+	a.Equal(`{"type":"string","x-foo":1}`, string(data))
+}
+
+func TestRawSchema_Dependencies(t *testing.T) {
+	a := require.New(t)
+
+	const input = `{
+	"dependencies": {
+		"z": {"required": ["a"]},
+		"b": ["c", "a"],
+		"y": true,
+		"a": []
+	},
+	"dependentRequired": {"z": ["a"], "b": []},
+	"dependentSchemas": {"z": {"type": "object"}, "b": false}
+}`
+	var raw RawSchema
+	a.NoError(json.Unmarshal([]byte(input), &raw))
+	a.Equal([]string{"c", "a"}, raw.Dependencies.Required["b"])
+	a.Equal("z", raw.DependentRequired[0].Name)
+	a.Equal("z", raw.DependentSchemas[0].Name)
+
+	// Order of dependencies is preserved.
+	data, err := json.Marshal(raw)
+	a.NoError(err)
+	a.Equal(compactJSON(t, input), string(data))
+
+	// Added dependencies are encoded in sorted order.
+	raw.Dependencies.Required["x"] = []string{"a"}
+	raw.Dependencies.Schemas["c"] = RawSchema{Type: SchemaType{"string"}}
+	delete(raw.Dependencies.Required, "b")
+	data, err = json.Marshal(raw.Dependencies)
+	a.NoError(err)
+	a.Equal(`{"z":{"required":["a"]},"y":true,"a":[],"c":{"type":"string"},"x":["a"]}`, string(data))
+}
+
+func TestRawSchema_RoundTrip(t *testing.T) {
+	dirs, err := fs.ReadDir(bench, "_bench")
+	require.NoError(t, err)
+	for _, dir := range dirs {
+		name := path.Join("_bench", dir.Name(), "schema.json")
+		t.Run(dir.Name(), func(t *testing.T) {
+			a := require.New(t)
+			input, err := fs.ReadFile(bench, name)
+			a.NoError(err)
+
+			var raw RawSchema
+			a.NoError(json.Unmarshal(input, &raw))
+			data, err := json.Marshal(raw)
+			a.NoError(err)
+
+			// Round-trip is exact up to whitespace and HTML escaping of
+			// json.Marshal.
+			var expected bytes.Buffer
+			json.HTMLEscape(&expected, []byte(compactJSON(t, string(input))))
+			a.Equal(expected.String(), string(data))
+		})
+	}
+}
+
+func extraKeys(e Extras) (r []string) {
+	for _, f := range e {
+		r = append(r, f.Key)
+	}
+	return r
+}
+
+func compactJSON(t *testing.T, s string) string {
+	t.Helper()
+	var b bytes.Buffer
+	require.NoError(t, json.Compact(&b, []byte(s)))
+	return b.String()
+}
@@ -1,14 +1,15 @@
 package jsonschema
 
 import (
-	"context"
 	"encoding/json"
 	"net/url"
+	"strings"
 
 	"github.com/go-faster/errors"
 )
 
-const maxResolveDepth = 1000
+// defaultMaxRefDepth is a default limit of nested references resolution.
+const defaultMaxRefDepth = 1000
 
 func stripFragment(u *url.URL) (loc url.URL) {
 	// Make copy.
@@ -17,25 +18,85 @@ func stripFragment(u *url.URL) (loc url.URL) {
 	return loc
 }
 
+// resolveReference resolves URI reference against given base URI.
+//
+// Unlike url.URL.ResolveReference, it keeps the result relative if base
+// is a relative-path reference, e.g. "main.json" against "node.json"
+// gives "main.json", not "/main.json".
+func resolveReference(base, ref *url.URL) *url.URL {
+	if base.IsAbs() || base.Host != "" || strings.HasPrefix(base.Path, "/") ||
+		ref.IsAbs() || ref.Host != "" || strings.HasPrefix(ref.Path, "/") {
+		return base.ResolveReference(ref)
+	}
+	rooted := *base
+	rooted.Path = "/" + base.Path
+	rooted.RawPath = ""
+	u := rooted.ResolveReference(ref)
+	u.Path = strings.TrimPrefix(u.Path, "/")
+	u.RawPath = ""
+	return u
+}
+
 type resolveCtx struct {
-	depth  int
+	depth int
+	// draft is a draft of the current document.
+	draft  *draft
 	parent *url.URL
+	// ptr is a JSON Pointer to the current schema, relative to parent.
+	ptr string
+	// anchor is set if schema is found by plain name fragment.
+	anchor bool
+	// identified is set if parent is already an identifier of the schema.
+	identified bool
 }
 
-func newResolveCtx(parent *url.URL) *resolveCtx {
+func newResolveCtx(dr *draft, parent *url.URL) *resolveCtx {
 	return &resolveCtx{
+		draft:  dr,
 		parent: parent,
 	}
 }
 
-func (r *resolveCtx) child(newParent *url.URL) *resolveCtx {
+func (r *resolveCtx) child(newParent *url.URL, ptr string) *resolveCtx {
 	return &resolveCtx{
+		depth:  r.depth,
+		draft:  r.draft,
 		parent: newParent,
+		ptr:    ptr,
+	}
+}
+
+// sub returns context of the subschema located by given reference tokens.
+func (r *resolveCtx) sub(tokens ...string) *resolveCtx {
+	ptr := r.ptr
+	for _, token := range tokens {
+		ptr += "/" + escape(token)
+	}
+	return &resolveCtx{
+		depth:  r.depth,
+		draft:  r.draft,
+		parent: r.parent,
+		ptr:    ptr,
 	}
 }
 
-func (r *resolveCtx) add() error {
-	if r.depth+1 >= maxResolveDepth {
+// resource reports whether the current schema is a root of schema resource.
+func (r *resolveCtx) resource() bool {
+	return r.ptr == "" && !r.anchor
+}
+
+// location returns absolute location of the current schema.
+func (r *resolveCtx) location() string {
+	var loc string
+	if r.parent != nil {
+		u := stripFragment(r.parent)
+		loc = u.String()
+	}
+	return loc + "#" + r.ptr
+}
+
+func (r *resolveCtx) add(limit int) error {
+	if r.depth+1 >= limit {
 		return errors.New("resolve depth exceeded")
 	}
 	r.depth++
@@ -47,24 +108,29 @@ func (r *resolveCtx) delete() {
 }
 
 func (r *resolveCtx) parseURL(ref string) (*url.URL, error) {
-	if r.parent != nil {
-		return r.parent.Parse(ref)
+	u, err := url.Parse(ref)
+	if err != nil || r.parent == nil {
+		return u, err
 	}
-	return url.Parse(ref)
+	return resolveReference(r.parent, u), nil
 }
 
-func (p *compiler) resolve(ref string, ctx *resolveCtx) (*Schema, error) {
-	if s, ok := p.refcache[ref]; ok {
-		return s, nil
-	}
-
+func (p *parser) resolve(ref string, ctx *resolveCtx) (*Schema, error) {
 	u, err := ctx.parseURL(ref)
 	if err != nil {
 		return nil, errors.Wrap(err, "parse ref")
 	}
+	key := u.String()
+	if s, ok := p.refcache[key]; ok {
+		return s, nil
+	}
 	locURL := stripFragment(u)
 
-	if err := ctx.add(); err != nil {
+	limit := p.opts.MaxRefDepth
+	if limit <= 0 {
+		limit = defaultMaxRefDepth
+	}
+	if err := ctx.add(limit); err != nil {
 		return nil, err
 	}
 	defer func() {
@@ -72,12 +138,22 @@ func (p *compiler) resolve(ref string, ctx *resolveCtx) (*Schema, error) {
 		ctx.delete()
 	}()
 
-	newURL, root, err := p.resolveURL(u, locURL.String())
+	newURL, doc, root, err := p.resolveURL(u, locURL.String(), ctx.draft)
 	if err != nil {
 		return nil, errors.Wrap(err, "resolve URL")
 	}
+	var (
+		ptr    string
+		anchor bool
+	)
 	if newURL != nil {
 		locURL = stripFragment(newURL)
+		switch f := newURL.Fragment; {
+		case strings.HasPrefix(f, "/"):
+			ptr = f
+		case f != "":
+			anchor = !p.isResourceRoot(&locURL, root, doc.draft)
+		}
 	}
 
 	var raw RawSchema
@@ -85,28 +161,65 @@ func (p *compiler) resolve(ref string, ctx *resolveCtx) (*Schema, error) {
 		return nil, errors.Wrap(err, "unmarshal")
 	}
 
-	return p.compile1(raw, ctx.child(&locURL), func(s *Schema) {
-		p.refcache[ref] = s
+	child := ctx.child(&locURL, ptr)
+	child.draft = doc.draft
+	child.anchor = anchor
+	child.identified = ptr == "" && !anchor && doc.isIdentified(&locURL, root)
+	return p.compile1(raw, child, func(s *Schema) {
+		p.refcache[key] = s
 	})
 }
 
-func (p *compiler) resolveURL(u *url.URL, loc string) (*url.URL, []byte, error) {
-	if val, ok := p.doc.resolveID(u); ok {
-		return u, val, nil
+// isResourceRoot reports whether given schema data is a root of the schema
+// resource.
+func (p *parser) isResourceRoot(loc *url.URL, data []byte, dr *draft) bool {
+	_, _, root, err := p.resolveURL(loc, loc.String(), dr)
+	if err != nil || len(root) != len(data) {
+		return false
+	}
+	// Anchor points to the same slice as the resource.
+	return len(root) == 0 || &root[0] == &data[0]
+}
+
+// resolveURL finds schema by given URL and returns the document containing it.
+//
+// Remote document without "$schema" keyword inherits given draft of the
+// referencing document.
+func (p *parser) resolveURL(u *url.URL, loc string, inherited *draft) (*url.URL, *document, []byte, error) {
+	if r, val, ok, err := p.doc.lookup(u); ok {
+		return r, p.doc, val, err
 	}
 	doc, ok := p.remotes[loc]
 	if !ok {
 		var err error
-		data, err := p.remote.Resolve(context.TODO(), loc)
-		if err != nil {
-			return nil, nil, errors.Wrapf(err, "remote %q", loc)
+		data, ok := metaSchema(loc)
+		if !ok {
+			if limit := p.opts.MaxRemotes; limit > 0 && p.fetched >= limit {
+				return nil, nil, nil, errors.Errorf("remote %q: too many remote documents (limit %d)", loc, limit)
+			}
+			p.fetched++
+
+			data, err = p.remote.Resolve(p.ctx, loc)
+			if err != nil {
+				return nil, nil, nil, errors.Wrapf(err, "remote %q", loc)
+			}
 		}
 
-		doc, err = collectIDs(nil, data)
+		dr, err := p.opts.draftOr(data, inherited)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if p.opts.validateSchema() {
+			if err := validateSchema(dr, loc, data); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		doc, err = collectIDs(dr, nil, data)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 		p.remotes[loc] = doc
 	}
-	return doc.resolve(u)
+	r, val, err := doc.resolve(u)
+	return r, doc, val, err
 }
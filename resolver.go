@@ -1,12 +1,16 @@
 package jsonschema
 
 import (
+	"container/list"
 	"context"
 	"io"
 	"io/fs"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-faster/errors"
 )
@@ -29,6 +33,9 @@ func (n NoRemote) Resolve(ctx context.Context, loc string) ([]byte, error) {
 var _ RemoteResolver = Remote{}
 
 // Remote is built-in implementation of RemoteResolver.
+//
+// Remote does not restrict loaded URLs, use HTTPResolver to compile
+// untrusted schemas.
 type Remote struct {
 	HTTPClient    *http.Client
 	AllowRelative bool
@@ -93,3 +100,236 @@ func (n Remote) Resolve(ctx context.Context, loc string) ([]byte, error) {
 		return nil, errors.Errorf("unknown scheme %q", u.Scheme)
 	}
 }
+
+var _ RemoteResolver = FSResolver{}
+
+// FSResolver is RemoteResolver, that loads documents from file systems,
+// like embed.FS or os.DirFS.
+//
+// Location is matched against URI prefixes, the longest matching prefix
+// wins. The rest of location is used as a path in the file system.
+type FSResolver struct {
+	// Prefixes maps URI prefixes to file systems.
+	//
+	// Prefix should end with a slash, e.g. "https://example.com/schemas/".
+	// Empty prefix matches every location, including relative ones.
+	Prefixes map[string]fs.FS
+}
+
+// NewFSResolver creates FSResolver, that maps given prefix to fsys.
+func NewFSResolver(prefix string, fsys fs.FS) FSResolver {
+	return FSResolver{
+		Prefixes: map[string]fs.FS{
+			prefix: fsys,
+		},
+	}
+}
+
+// longestPrefix finds value of the longest prefix of given location.
+//
+// Returns value and the rest of location.
+func longestPrefix[V any](prefixes map[string]V, loc string) (val V, rest string, ok bool) {
+	var match string
+	for prefix, v := range prefixes {
+		if !strings.HasPrefix(loc, prefix) {
+			continue
+		}
+		if !ok || len(prefix) > len(match) {
+			match, val, ok = prefix, v, true
+		}
+	}
+	if !ok {
+		return val, "", false
+	}
+	return val, loc[len(match):], true
+}
+
+// Resolve implements RemoteResolver.
+func (r FSResolver) Resolve(ctx context.Context, loc string) ([]byte, error) {
+	fsys, name, ok := longestPrefix(r.Prefixes, loc)
+	if !ok {
+		return nil, errors.Errorf("no file system for %q", loc)
+	}
+
+	name, err := url.PathUnescape(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "unescape path")
+	}
+	name = strings.TrimPrefix(name, "/")
+	if !fs.ValidPath(name) {
+		return nil, errors.Errorf("invalid path %q", name)
+	}
+
+	return fs.ReadFile(fsys, name)
+}
+
+var _ RemoteResolver = PrefixResolver{}
+
+// PrefixResolver is RemoteResolver, that passes location to the resolver
+// of the longest matching URI prefix.
+//
+// Empty prefix matches every location.
+type PrefixResolver map[string]RemoteResolver
+
+// Resolve implements RemoteResolver.
+func (r PrefixResolver) Resolve(ctx context.Context, loc string) ([]byte, error) {
+	resolver, _, ok := longestPrefix(r, loc)
+	if !ok {
+		return nil, errors.Errorf("no resolver for %q", loc)
+	}
+	return resolver.Resolve(ctx, loc)
+}
+
+var _ RemoteResolver = ChainResolver{}
+
+// ChainResolver is RemoteResolver, that tries resolvers in order and
+// returns the first successful result.
+//
+// For example, to look up schemas in the embedded file system first and
+// then load them over HTTP:
+//
+//	ChainResolver{
+//		NewFSResolver("https://example.com/schemas/", schemas),
+//		Remote{},
+//	}
+type ChainResolver []RemoteResolver
+
+// Resolve implements RemoteResolver.
+func (r ChainResolver) Resolve(ctx context.Context, loc string) ([]byte, error) {
+	if len(r) == 0 {
+		return nil, errors.New("no resolvers")
+	}
+	errs := make([]error, 0, len(r))
+	for _, resolver := range r {
+		data, err := resolver.Resolve(ctx, loc)
+		if err == nil {
+			return data, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+var _ RemoteResolver = (*CacheResolver)(nil)
+
+// CacheOptions is a CacheResolver options.
+type CacheOptions struct {
+	// TTL is a time to live of cached document.
+	//
+	// Zero means documents never expire.
+	TTL time.Duration
+	// Size is a maximum number of cached documents. The least recently
+	// used document is evicted first.
+	//
+	// Zero means no limit.
+	Size int
+}
+
+// CacheResolver is RemoteResolver, that caches documents loaded by
+// another resolver.
+//
+// Errors are not cached. CacheResolver is safe for concurrent use,
+// if underlying resolver is. Returned data must not be modified.
+//
+// Only raw documents are cached, so every compilation still parses
+// them. To share parsed documents between compilations, add them to
+// the Compiler by AddResource.
+type CacheResolver struct {
+	resolver RemoteResolver
+	opts     CacheOptions
+	now      func() time.Time
+
+	mux     sync.Mutex
+	entries map[string]*list.Element
+	// lru is a list of cacheEntry, the most recently used first.
+	lru *list.List
+}
+
+type cacheEntry struct {
+	loc     string
+	data    []byte
+	expires time.Time
+}
+
+// NewCacheResolver creates new CacheResolver.
+func NewCacheResolver(r RemoteResolver, opts CacheOptions) *CacheResolver {
+	return &CacheResolver{
+		resolver: r,
+		opts:     opts,
+		now:      time.Now,
+		entries:  map[string]*list.Element{},
+		lru:      list.New(),
+	}
+}
+
+func (c *CacheResolver) get(loc string) ([]byte, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	elem, ok := c.entries[loc]
+	if !ok {
+		return nil, false
+	}
+	e := elem.Value.(*cacheEntry)
+	if !e.expires.IsZero() && !c.now().Before(e.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return e.data, true
+}
+
+func (c *CacheResolver) put(loc string, data []byte) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	e := &cacheEntry{
+		loc:  loc,
+		data: data,
+	}
+	if ttl := c.opts.TTL; ttl > 0 {
+		e.expires = c.now().Add(ttl)
+	}
+	if elem, ok := c.entries[loc]; ok {
+		elem.Value = e
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[loc] = c.lru.PushFront(e)
+
+	if size := c.opts.Size; size > 0 {
+		for c.lru.Len() > size {
+			c.remove(c.lru.Back())
+		}
+	}
+}
+
+func (c *CacheResolver) remove(elem *list.Element) {
+	e := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, e.loc)
+}
+
+// Resolve implements RemoteResolver.
+func (c *CacheResolver) Resolve(ctx context.Context, loc string) ([]byte, error) {
+	if data, ok := c.get(loc); ok {
+		return data, nil
+	}
+	data, err := c.resolver.Resolve(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	c.put(loc, data)
+	return data, nil
+}
+
+// Purge removes all cached documents.
+func (c *CacheResolver) Purge() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.entries = map[string]*list.Element{}
+	c.lru.Init()
+}
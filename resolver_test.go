@@ -0,0 +1,212 @@
+package jsonschema
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSResolver(t *testing.T) {
+	common := fstest.MapFS{
+		"address.json":  {Data: []byte(`{"type": "object", "required": ["city"]}`)},
+		"my name.json":  {Data: []byte(`{"type": "string"}`)},
+		"v2/count.json": {Data: []byte(`{"type": "integer"}`)},
+	}
+	v2 := fstest.MapFS{
+		"count.json": {Data: []byte(`{"type": "number"}`)},
+	}
+	r := FSResolver{
+		Prefixes: map[string]fs.FS{
+			"":                               common,
+			"https://example.com/common/":    common,
+			"https://example.com/common/v2/": v2,
+		},
+	}
+
+	t.Run("Resolve", func(t *testing.T) {
+		ctx := context.Background()
+		for i, tt := range []struct {
+			loc     string
+			want    string
+			wantErr bool
+		}{
+			{"common/address.json", "", true},
+			{"address.json", `{"type": "object", "required": ["city"]}`, false},
+			{"https://example.com/common/my%20name.json", `{"type": "string"}`, false},
+			// The longest prefix wins.
+			{"https://example.com/common/v2/count.json", `{"type": "number"}`, false},
+			{"https://example.com/common/../secret.json", "", true},
+			{"https://example.com/common/", "", true},
+		} {
+			data, err := r.Resolve(ctx, tt.loc)
+			if tt.wantErr {
+				require.Error(t, err, "test %d: %s", i+1, tt.loc)
+				continue
+			}
+			require.NoError(t, err, "test %d: %s", i+1, tt.loc)
+			require.JSONEq(t, tt.want, string(data), "test %d: %s", i+1, tt.loc)
+		}
+	})
+	t.Run("Parse", func(t *testing.T) {
+		a := require.New(t)
+
+		s, err := ParseWithOptions([]byte(`{"properties": {
+	"address": {"$ref": "address.json"},
+	"count": {"$ref": "https://example.com/common/v2/count.json"}
+}}`), Options{Remote: r})
+		a.NoError(err)
+		a.NoError(s.Validate([]byte(`{"address": {"city": "Moscow"}, "count": 1.5}`)))
+		a.Error(s.Validate([]byte(`{"address": {}}`)))
+		a.Error(s.Validate([]byte(`{"count": "1"}`)))
+	})
+	t.Run("NoPrefix", func(t *testing.T) {
+		_, err := NewFSResolver("https://example.com/", common).Resolve(context.Background(), "address.json")
+		require.Error(t, err)
+	})
+}
+
+type countingResolver struct {
+	RemoteResolver
+	calls map[string]int
+}
+
+func (r *countingResolver) Resolve(ctx context.Context, loc string) ([]byte, error) {
+	r.calls[loc]++
+	return r.RemoteResolver.Resolve(ctx, loc)
+}
+
+func TestCacheResolver(t *testing.T) {
+	ctx := context.Background()
+	newResolver := func(opts CacheOptions) (*CacheResolver, *countingResolver, *time.Time) {
+		r := &countingResolver{
+			RemoteResolver: mapResolver{
+				"https://example.com/a.json": `{"type": "string"}`,
+				"https://example.com/b.json": `{"type": "number"}`,
+				"https://example.com/c.json": `{"type": "object"}`,
+			},
+			calls: map[string]int{},
+		}
+		now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		c := NewCacheResolver(r, opts)
+		c.now = func() time.Time { return now }
+		return c, r, &now
+	}
+
+	t.Run("Cache", func(t *testing.T) {
+		a := require.New(t)
+		c, r, _ := newResolver(CacheOptions{})
+
+		for i := 0; i < 3; i++ {
+			data, err := c.Resolve(ctx, "https://example.com/a.json")
+			a.NoError(err)
+			a.Equal(`{"type": "string"}`, string(data))
+
+			_, err = c.Resolve(ctx, "https://example.com/unknown.json")
+			a.Error(err)
+		}
+		a.Equal(1, r.calls["https://example.com/a.json"])
+		// Errors are not cached.
+		a.Equal(3, r.calls["https://example.com/unknown.json"])
+
+		c.Purge()
+		_, err := c.Resolve(ctx, "https://example.com/a.json")
+		a.NoError(err)
+		a.Equal(2, r.calls["https://example.com/a.json"])
+	})
+	t.Run("TTL", func(t *testing.T) {
+		a := require.New(t)
+		c, r, now := newResolver(CacheOptions{TTL: time.Minute})
+
+		_, err := c.Resolve(ctx, "https://example.com/a.json")
+		a.NoError(err)
+		*now = now.Add(30 * time.Second)
+		_, err = c.Resolve(ctx, "https://example.com/a.json")
+		a.NoError(err)
+		a.Equal(1, r.calls["https://example.com/a.json"])
+
+		*now = now.Add(30 * time.Second)
+		_, err = c.Resolve(ctx, "https://example.com/a.json")
+		a.NoError(err)
+		a.Equal(2, r.calls["https://example.com/a.json"])
+	})
+	t.Run("Size", func(t *testing.T) {
+		a := require.New(t)
+		c, r, _ := newResolver(CacheOptions{Size: 2})
+
+		for _, loc := range []string{
+			"https://example.com/a.json",
+			"https://example.com/b.json",
+			// Make "a" the most recently used.
+			"https://example.com/a.json",
+			// Evicts "b".
+			"https://example.com/c.json",
+			"https://example.com/a.json",
+			"https://example.com/b.json",
+		} {
+			_, err := c.Resolve(ctx, loc)
+			a.NoError(err)
+		}
+		a.Equal(map[string]int{
+			"https://example.com/a.json": 1,
+			"https://example.com/b.json": 2,
+			"https://example.com/c.json": 1,
+		}, r.calls)
+	})
+	t.Run("Compile", func(t *testing.T) {
+		a := require.New(t)
+		c, r, _ := newResolver(CacheOptions{})
+
+		for i := 0; i < 5; i++ {
+			s, err := ParseWithOptions([]byte(`{"$ref": "https://example.com/a.json"}`), Options{Remote: c})
+			a.NoError(err)
+			a.Error(s.Validate([]byte(`1`)))
+		}
+		a.Equal(1, r.calls["https://example.com/a.json"])
+	})
+}
+
+func TestChainResolver(t *testing.T) {
+	ctx := context.Background()
+	a := require.New(t)
+
+	schemas := fstest.MapFS{
+		"a.json": {Data: []byte(`{"type": "string"}`)},
+	}
+	r := ChainResolver{
+		PrefixResolver{
+			"https://internal.example.com/": mapResolver{
+				"https://internal.example.com/b.json": `{"type": "number"}`,
+			},
+		},
+		NewFSResolver("https://example.com/", schemas),
+		mapResolver{
+			"https://example.com/a.json": `{"type": "null"}`,
+			"https://example.com/c.json": `{"type": "object"}`,
+		},
+	}
+
+	for loc, want := range map[string]string{
+		"https://internal.example.com/b.json": `{"type": "number"}`,
+		"https://example.com/a.json":          `{"type": "string"}`,
+		"https://example.com/c.json":          `{"type": "object"}`,
+	} {
+		data, err := r.Resolve(ctx, loc)
+		a.NoError(err, loc)
+		a.Equal(want, string(data), loc)
+	}
+
+	_, err := r.Resolve(ctx, "https://example.com/unknown.json")
+	a.Error(err)
+
+	_, err = ChainResolver{}.Resolve(ctx, "https://example.com/a.json")
+	a.Error(err)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = ChainResolver{ctxResolver{}, NewFSResolver("https://example.com/", schemas)}.Resolve(canceled, "https://example.com/a.json")
+	a.ErrorIs(err, context.Canceled)
+}
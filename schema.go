@@ -3,11 +3,11 @@ package jsonschema
 import (
 	"encoding/json"
 	"math/big"
-	"regexp"
+	"strings"
 )
 
 type patternProperty struct {
-	Regexp *regexp.Regexp
+	Regexp Regexp
 	Schema *Schema
 }
 
@@ -64,6 +64,31 @@ func (t typeSet) has(typ typeSet) bool {
 	return t == 0 || t&typ != 0
 }
 
+var typeNames = [...]string{
+	"string",
+	"number",
+	"integer",
+	"null",
+	"boolean",
+	"array",
+	"object",
+}
+
+// names returns list of type names in the set.
+func (t typeSet) names() (r []string) {
+	for i, name := range typeNames {
+		if t&(1<<i) != 0 {
+			r = append(r, name)
+		}
+	}
+	return r
+}
+
+// String returns name of the type.
+func (t typeSet) String() string {
+	return strings.Join(t.names(), ", ")
+}
+
 type (
 	additional struct {
 		Set    bool
@@ -78,6 +103,12 @@ func (a additional) isSchema() bool {
 	return a.Set && a.Schema != nil
 }
 
+// number is a compiled JSON number.
+type number struct {
+	Rat *big.Rat
+	Raw Num
+}
+
 type items struct {
 	Set    bool
 	Object *Schema
@@ -86,11 +117,45 @@ type items struct {
 
 // Schema is a parsed schema structure.
 type Schema struct {
-	types  typeSet
+	// loc is an absolute location of the schema.
+	loc string
+	// ref is a referenced schema.
+	ref *Schema
+	// refOverride is set if "$ref" overrides sibling keywords.
+	//
+	// Since Draft 2019-09, "$ref" is an applicator like "allOf".
+	refOverride bool
+	// recursiveRef is a statically resolved "$recursiveRef" target.
+	recursiveRef *Schema
+	// recursiveAnchor is a value of "$recursiveAnchor" keyword.
+	recursiveAnchor bool
+	// dynamicRef is a statically resolved "$dynamicRef" target.
+	dynamicRef *Schema
+	// dynamicRefAnchor is a name of dynamic anchor to look up in the
+	// dynamic scope, if "$dynamicRef" target is a dynamic anchor.
+	dynamicRefAnchor string
+	// dynamicAnchor is a value of "$dynamicAnchor" keyword.
+	dynamicAnchor string
+	// resource is set if schema is a root of schema resource.
+	resource bool
+	// dynamicAnchors maps dynamic anchors of schema resource to schemas.
+	dynamicAnchors map[string]*Schema
+
+	// never is set if schema is a false boolean schema.
+	never bool
+
+	types typeSet
+	// integralFloats is set if numbers with zero fractional part, like
+	// 1.0, are integers, since Draft 6.
+	integralFloats bool
+	// format is a value of "format" keyword.
 	format string
+	// formatCheck is set, if format is asserted.
+	formatCheck FormatFunc
 
-	enum    []json.RawMessage
-	enumMap map[string]struct{}
+	enum     []json.RawMessage
+	enumMap  map[string]struct{}
+	constant json.RawMessage
 
 	// Schema composition.
 	allOf []*Schema
@@ -98,15 +163,27 @@ type Schema struct {
 	oneOf []*Schema
 	not   *Schema
 
+	// Conditional validation.
+	ifSchema   *Schema
+	thenSchema *Schema
+	elseSchema *Schema
+
 	// Object validators.
-	minProperties        minMax
-	maxProperties        minMax
-	required             map[string]struct{}
-	properties           map[string]*Schema
+	minProperties minMax
+	maxProperties minMax
+	required      map[string]struct{}
+	properties    map[string]*Schema
+	// propertyOrder is a list of "properties" keys in definition order.
+	propertyOrder        []string
 	patternProperties    []patternProperty
 	additionalProperties additionalProperties
 	dependentRequired    map[string][]string
 	dependentSchemas     map[string]*Schema
+	// dependentKeywords is set if dependencies are defined by
+	// "dependentRequired" and "dependentSchemas" instead of "dependencies".
+	dependentKeywords     bool
+	propertyNames         *Schema
+	unevaluatedProperties *Schema
 
 	// Array validators.
 	minItems        minMax
@@ -114,17 +191,45 @@ type Schema struct {
 	uniqueItems     bool
 	items           items
 	additionalItems additionalItems
+	// prefixItems is set if items.Array is defined by "prefixItems" and
+	// additionalItems by "items", since Draft 2020-12.
+	prefixItems bool
+	contains    *Schema
+	// containsEvaluates is set if items, matching "contains", are
+	// evaluated items, since Draft 2020-12.
+	containsEvaluates bool
+	minContains       minMax
+	maxContains       minMax
+	unevaluatedItems  *Schema
 
 	// Number validators.
 	// TODO: try to store small numbers as int64
-	minimum          *big.Rat
+	minimum          *number
 	exclusiveMinimum bool
-	maximum          *big.Rat
+	maximum          *number
 	exclusiveMaximum bool
-	multipleOf       *big.Rat
+	// Since Draft 6, "exclusiveMinimum" and "exclusiveMaximum" are numbers.
+	minimumExclusive *number
+	maximumExclusive *number
+	multipleOf       *number
 
 	// String validators.
 	minLength minMax
 	maxLength minMax
-	pattern   *regexp.Regexp
+	pattern   Regexp
+
+	// String content.
+	contentEncoding  string
+	contentMediaType string
+	// contentCheck is set, if content is asserted.
+	contentCheck bool
+
+	// Annotations.
+	title        string
+	description  string
+	defaultValue json.RawMessage
+	examples     []json.RawMessage
+	deprecated   bool
+	readOnly     bool
+	writeOnly    bool
 }
@@ -27,5 +27,63 @@ func ExampleParse() {
 
 	fmt.Println(schema.Validate([]byte(`{"number": "1600"}`)))
 	// Output:
-	// object: "number": string: type is not allowed
+	// #/number: type: string is not allowed
+}
+
+func ExampleCompiler() {
+	c := jsonschema.NewCompiler(jsonschema.Options{
+		// Resolve references only to added resources.
+		Remote: jsonschema.NoRemote{},
+	})
+	if err := c.AddResource("https://example.com/port.json", []byte(`{
+  "type": "integer",
+  "minimum": 1,
+  "maximum": 65535
+}`)); err != nil {
+		panic(err)
+	}
+
+	schema, err := c.Compile([]byte(`{
+  "type": "object",
+  "properties": {
+    "port": { "$ref": "https://example.com/port.json" }
+  }
+}`))
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(schema.Validate([]byte(`{"port": 0}`)))
+	// Output:
+	// #/port: minimum: value 0 is smaller than 1
+}
+
+func ExampleUnmarshal() {
+	schema, err := jsonschema.Parse([]byte(`{
+  "type": "object",
+  "required": ["name"],
+  "properties": {
+    "name": { "type": "string", "minLength": 1 },
+    "port": { "type": "integer", "minimum": 1, "maximum": 65535 }
+  }
+}`))
+	if err != nil {
+		panic(err)
+	}
+
+	var config struct {
+		Name string `json:"name"`
+		Port int    `json:"port"`
+	}
+	if err := jsonschema.Unmarshal(schema, []byte(`{"name": "api", "port": 8080}`), &config); err != nil {
+		panic(err)
+	}
+	fmt.Println(config.Name, config.Port)
+
+	fmt.Println(jsonschema.Unmarshal(schema, []byte(`{"name": "api", "port": 0}`), &config))
+	fmt.Println(config.Name, config.Port)
+	// Output:
+	// api 8080
+	// #/port: minimum: value 0 is smaller than 1
+	// api 8080
 }
@@ -0,0 +1,261 @@
+package jsonschema
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"runtime"
+
+	"github.com/go-faster/errors"
+)
+
+// StreamFormat is a format of JSON records stream.
+type StreamFormat int
+
+const (
+	// NDJSON is newline-delimited JSON, one record per line.
+	//
+	// Blank lines are skipped.
+	NDJSON StreamFormat = iota
+	// JSONSeq is JSON text sequence, defined by RFC 7464.
+	//
+	// Each record is preceded by RS (0x1E) character and may span multiple
+	// lines.
+	JSONSeq
+)
+
+// String implements fmt.Stringer.
+func (f StreamFormat) String() string {
+	switch f {
+	case NDJSON:
+		return "ndjson"
+	case JSONSeq:
+		return "json-seq"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultMaxRecordSize is a default limit of stream record size.
+const DefaultMaxRecordSize = 16 << 20
+
+// StreamOptions is a ValidateStream options.
+type StreamOptions struct {
+	// Format is a format of the stream.
+	//
+	// Defaults to NDJSON.
+	Format StreamFormat
+	// Workers is a number of records, validated concurrently.
+	//
+	// Defaults to runtime.GOMAXPROCS(0).
+	Workers int
+	// MaxRecordSize limits size of a single record.
+	//
+	// Defaults to DefaultMaxRecordSize.
+	MaxRecordSize int
+	// All enables collecting of all validation errors of record, like
+	// ValidateAll does.
+	All bool
+}
+
+// Record is a validation result of stream record.
+type Record struct {
+	// Index is a zero-based index of the record in the stream.
+	Index int
+	// Line is a one-based number of the line, where the record starts.
+	Line int
+	// Err is a validation error, nil if record is valid.
+	//
+	// Err is *ValidationError or ValidationErrors, if record is a valid
+	// JSON, and syntax error otherwise.
+	Err error
+}
+
+// ValidateStream validates every record of given stream.
+//
+// Records are validated concurrently, fn is called with results in input
+// order. If fn returns error, ValidateStream stops and returns it.
+//
+// Invalid records are reported to fn and do not stop validation. Returned
+// error is non-nil only if stream can't be read, record is too large,
+// context is done or fn fails. Reading of r is not interrupted by
+// context, so r should be closed by caller to release reading goroutine.
+func (s *Schema) ValidateStream(ctx context.Context, r io.Reader, opts StreamOptions, fn func(Record) error) error {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	if opts.MaxRecordSize <= 0 {
+		opts.MaxRecordSize = DefaultMaxRecordSize
+	}
+	validate := s.Validate
+	if opts.All {
+		validate = s.ValidateAll
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type job struct {
+		record Record
+		data   []byte
+		done   chan Record
+	}
+	var (
+		jobs = make(chan *job)
+		// order keeps jobs in input order, limiting number of pending
+		// results.
+		order   = make(chan *job, 2*opts.Workers)
+		readErr = make(chan error, 1)
+	)
+
+	// Reader.
+	go func() {
+		defer close(order)
+		defer close(jobs)
+
+		readErr <- scanRecords(r, opts, func(rec Record, data []byte) error {
+			j := &job{
+				record: rec,
+				data:   data,
+				done:   make(chan Record, 1),
+			}
+			select {
+			case order <- j:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			select {
+			case jobs <- j:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		})
+	}()
+
+	// Workers.
+	for range opts.Workers {
+		go func() {
+			for j := range jobs {
+				rec := j.record
+				if rec.Err == nil {
+					rec.Err = validate(j.data)
+				}
+				j.done <- rec
+			}
+		}()
+	}
+
+	for j := range order {
+		var rec Record
+		select {
+		case rec = <-j.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return <-readErr
+}
+
+// recordSeparator is a record separator of JSON text sequence.
+const recordSeparator = 0x1E
+
+// scanRecords reads records from given stream and calls fn for each one.
+//
+// Data, passed to fn, may be retained.
+func scanRecords(r io.Reader, opts StreamOptions, fn func(rec Record, data []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, opts.MaxRecordSize)
+	switch opts.Format {
+	case NDJSON:
+		scanner.Split(bufio.ScanLines)
+	case JSONSeq:
+		scanner.Split(scanSeq)
+	default:
+		return errors.Errorf("unknown format %d", opts.Format)
+	}
+
+	var (
+		index int
+		// line is a number of the line, where the next chunk starts.
+		line = 1
+	)
+	for scanner.Scan() {
+		chunk := scanner.Bytes()
+		start := line
+		if opts.Format == NDJSON {
+			line++
+		} else {
+			line += bytes.Count(chunk, []byte{'\n'})
+		}
+
+		data := bytes.TrimLeftFunc(chunk, isSpaceRune)
+		start += bytes.Count(chunk[:len(chunk)-len(data)], []byte{'\n'})
+		data = bytes.TrimRightFunc(data, isSpaceRune)
+		if len(data) == 0 {
+			continue
+		}
+
+		rec := Record{Index: index, Line: start}
+		if opts.Format == JSONSeq && !isSpace(chunk[len(chunk)-1]) {
+			// RFC 7464, section 2.4: number, "true", "false" or "null",
+			// not followed by whitespace, may be truncated.
+			if c := data[0]; c != '{' && c != '[' && c != '"' {
+				rec.Err = errors.New("record may be truncated")
+			}
+		}
+		index++
+
+		if err := fn(rec, bytes.Clone(data)); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return errors.Errorf("record %d at line %d is larger than %d bytes", index, line, opts.MaxRecordSize)
+		}
+		return errors.Wrap(err, "read")
+	}
+	return nil
+}
+
+// isSpace reports whether c is JSON whitespace.
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n':
+		return true
+	default:
+		return false
+	}
+}
+
+func isSpaceRune(r rune) bool {
+	return r < 0x80 && isSpace(byte(r))
+}
+
+// scanSeq is a bufio.SplitFunc, that splits JSON text sequence by record
+// separators.
+func scanSeq(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(data) > 0 && data[0] == recordSeparator {
+		if i := bytes.IndexByte(data[1:], recordSeparator); i >= 0 {
+			return i + 1, data[1 : i+1], nil
+		}
+		if atEOF {
+			return len(data), data[1:], nil
+		}
+		return 0, nil, nil
+	}
+
+	// Data before the first separator.
+	if i := bytes.IndexByte(data, recordSeparator); i >= 0 {
+		return i, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,123 @@
+package jsonschema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-faster/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_ValidateStream(t *testing.T) {
+	sch, err := Parse([]byte(`{"type": "object", "required": ["id"]}`))
+	require.NoError(t, err)
+
+	type result struct {
+		Index int
+		Line  int
+		Err   string
+	}
+	validate := func(t *testing.T, input string, opts StreamOptions) (r []result) {
+		require.NoError(t, sch.ValidateStream(context.Background(), strings.NewReader(input), opts, func(rec Record) error {
+			var msg string
+			if rec.Err != nil {
+				msg = rec.Err.Error()
+			}
+			r = append(r, result{Index: rec.Index, Line: rec.Line, Err: msg})
+			return nil
+		}))
+		return r
+	}
+
+	t.Run("NDJSON", func(t *testing.T) {
+		input := "{\"id\": 1}\n{}\n\n  \r\n[1]\r\n{\"id\": \n{\"id\": 2}"
+		require.Equal(t, []result{
+			{0, 1, ""},
+			{1, 2, `#: required: required property "id" is missing`},
+			{2, 5, `#: type: array is not allowed`},
+			{3, 6, `parse JSON: "," or "}" expected: unexpected byte 34 '"' at 5`},
+			{4, 7, ""},
+		}, validate(t, input, StreamOptions{Format: NDJSON, Workers: 2}))
+	})
+	t.Run("JSONSeq", func(t *testing.T) {
+		input := "\x1e{\"id\": 1}\n\x1e{\n  \"foo\": 1\n}\n\x1e\n\x1e\n\n{}\n\x1e1\x1e{\"id\": 2}\n"
+		require.Equal(t, []result{
+			{0, 1, ""},
+			{1, 2, `#: required: required property "id" is missing`},
+			{2, 8, `#: required: required property "id" is missing`},
+			{3, 9, `record may be truncated`},
+			{4, 9, ""},
+		}, validate(t, input, StreamOptions{Format: JSONSeq, Workers: 3}))
+	})
+	t.Run("Order", func(t *testing.T) {
+		const count = 1000
+		var b strings.Builder
+		for i := 0; i < count; i++ {
+			if i%3 == 0 {
+				b.WriteString("{}\n")
+			} else {
+				fmt.Fprintf(&b, "{\"id\": %d}\n", i)
+			}
+		}
+
+		r := validate(t, b.String(), StreamOptions{Workers: 8})
+		require.Len(t, r, count)
+		for i, rec := range r {
+			require.Equal(t, i, rec.Index)
+			require.Equal(t, i+1, rec.Line)
+			require.Equal(t, i%3 == 0, rec.Err != "", "record %d", i)
+		}
+	})
+	t.Run("All", func(t *testing.T) {
+		sch, err := Parse([]byte(`{"required": ["a", "b"]}`))
+		require.NoError(t, err)
+
+		var errs []error
+		require.NoError(t, sch.ValidateStream(context.Background(), strings.NewReader("{}"), StreamOptions{
+			All: true,
+		}, func(rec Record) error {
+			errs = append(errs, rec.Err)
+			return nil
+		}))
+		require.Len(t, errs, 1)
+
+		var list ValidationErrors
+		require.ErrorAs(t, errs[0], &list)
+		require.Len(t, list, 2)
+	})
+	t.Run("TooLarge", func(t *testing.T) {
+		input := "{}\n{\"id\": \"" + strings.Repeat("a", 100) + "\"}\n"
+		err := sch.ValidateStream(context.Background(), strings.NewReader(input), StreamOptions{
+			MaxRecordSize: 64,
+		}, func(rec Record) error {
+			return nil
+		})
+		require.EqualError(t, err, "record 1 at line 2 is larger than 64 bytes")
+	})
+	t.Run("Callback", func(t *testing.T) {
+		stop := errors.New("stop")
+		input := strings.Repeat("{}\n", 100)
+
+		var n int
+		err := sch.ValidateStream(context.Background(), strings.NewReader(input), StreamOptions{}, func(rec Record) error {
+			n++
+			if rec.Index == 10 {
+				return stop
+			}
+			return nil
+		})
+		require.ErrorIs(t, err, stop)
+		require.Equal(t, 11, n)
+	})
+	t.Run("Context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := sch.ValidateStream(ctx, strings.NewReader(strings.Repeat("{}\n", 100)), StreamOptions{}, func(rec Record) error {
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -3,7 +3,6 @@ package jsonschema
 import (
 	"embed"
 	"io/fs"
-	"net/http"
 	"path"
 	"testing"
 
@@ -14,20 +13,12 @@ var (
 	//go:embed _testdata
 	testdata embed.FS
 	remotes  = errors.Must(fs.Sub(testdata, path.Join("_testdata", "remotes")))
+
+	// suiteRemote serves remote documents of the test suite.
+	suiteRemote = NewFSResolver("http://localhost:1234/", remotes)
 )
 
 func TestJSONSchemaSuite(t *testing.T) {
-	h := http.Server{
-		Addr:    "localhost:1234",
-		Handler: http.FileServer(http.FS(remotes)),
-	}
-	defer h.Close()
-
-	go func() {
-		if err := h.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			t.Error(err)
-		}
-	}()
 	runSuite(t, testdata, path.Join("_testdata", "suite"))
 }
 
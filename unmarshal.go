@@ -0,0 +1,700 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/go-faster/errors"
+	"github.com/go-faster/jx"
+)
+
+// Unmarshal validates data against given schema and decodes it into the
+// value pointed to by v.
+//
+// Data is validated and decoded in one pass: every value is decoded right
+// after it is validated, using the same decoder. Values, validated by
+// keywords, that need more than one pass, like "allOf", "uniqueItems" or
+// "patternProperties", are buffered, like Validate does.
+//
+// Values are decoded like encoding/json does, honoring "json" tags,
+// json.Unmarshaler and encoding.TextUnmarshaler. Unlike json.Unmarshal, v
+// is set only if data is valid, so it is never decoded partially, and
+// decoded value replaces the current one instead of being merged into it.
+//
+// Validation stops at the first error. If data is a valid JSON, returned
+// validation error is *ValidationError.
+func Unmarshal(s *Schema, data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return &json.InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+
+	d := jx.GetDecoder()
+	defer jx.PutDecoder(d)
+	d.ResetBytes(data)
+
+	vd := getValidator()
+	defer putValidator(vd)
+
+	target := reflect.New(rv.Type().Elem()).Elem()
+	if err := s.decode(vd, d, target); err != nil {
+		return err
+	}
+	if err := d.Skip(); !errors.Is(err, io.EOF) {
+		return errors.New("invalid json: unexpected data after top-level value")
+	}
+	rv.Elem().Set(target)
+	return nil
+}
+
+// emptySchema accepts any value.
+//
+// Used to decode values, not checked by schema.
+var emptySchema = &Schema{
+	minProperties: -1,
+	maxProperties: -1,
+	minItems:      -1,
+	maxItems:      -1,
+	minContains:   -1,
+	maxContains:   -1,
+	minLength:     -1,
+	maxLength:     -1,
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// decode validates value and decodes it into rv.
+//
+// If rv is zero, value is only validated.
+func (s *Schema) decode(v *validator, d *jx.Decoder, rv reflect.Value) (rerr error) {
+	if !rv.IsValid() {
+		return s.validate(v, d)
+	}
+
+	if ref := s.ref; ref != nil && s.refOverride {
+		n := v.keyword.push("$ref")
+		defer v.keyword.pop(n)
+		return ref.decode(v, d, rv)
+	}
+
+	st := s.enter(v)
+	defer func() {
+		s.leave(v, st, rerr)
+	}()
+
+	tt := d.Next()
+	if tt == jx.Invalid {
+		return errors.Wrap(d.Validate(), "invalid json")
+	}
+
+	if s.never {
+		return v.fail(s, "", false, nil, "false schema does not allow any value")
+	}
+
+	if s.hasInPlace() {
+		data, err := d.Raw()
+		if err != nil {
+			return errors.Wrap(err, "invalid json")
+		}
+		if err := s.validateInPlace(v, data, func(sub *Schema) error {
+			return sub.validateRaw(v, data)
+		}); err != nil {
+			return err
+		}
+
+		d = jx.GetDecoder()
+		defer jx.PutDecoder(d)
+		d.ResetBytes(data)
+	}
+
+	u, tu, rv := indirect(rv, tt == jx.Null)
+	if u == nil && tu == nil {
+		return s.decodeType(v, d, tt, rv)
+	}
+
+	// Value is decoded by Go type itself, validate it separately.
+	data, err := d.Raw()
+	if err != nil {
+		return errors.Wrap(err, "invalid json")
+	}
+	sub := jx.GetDecoder()
+	defer jx.PutDecoder(sub)
+	sub.ResetBytes(data)
+	if err := s.validateType(v, sub, tt); err != nil {
+		return err
+	}
+	if u != nil {
+		return u.UnmarshalJSON(data)
+	}
+	if tt != jx.String {
+		return v.typeError(tt.String(), reflect.TypeOf(tu))
+	}
+	str, err := jx.DecodeBytes(data).StrBytes()
+	if err != nil {
+		return errors.Wrap(err, "parse JSON")
+	}
+	return tu.UnmarshalText(str)
+}
+
+// indirect allocates pointers, until it gets to a non-pointer value or to
+// a value, implementing json.Unmarshaler or encoding.TextUnmarshaler, like
+// encoding/json does.
+//
+// If null is set, indirect stops at the last pointer, so it can be set to
+// nil.
+func indirect(rv reflect.Value, null bool) (json.Unmarshaler, encoding.TextUnmarshaler, reflect.Value) {
+	// Start with the address of named value, so methods with pointer
+	// receiver are found.
+	v0 := rv
+	haveAddr := false
+	if rv.Kind() != reflect.Pointer && rv.Type().Name() != "" && rv.CanAddr() {
+		haveAddr = true
+		rv = rv.Addr()
+	}
+	for {
+		// Decode into the pointer, stored in interface.
+		if rv.Kind() == reflect.Interface && !rv.IsNil() {
+			e := rv.Elem()
+			if e.Kind() == reflect.Pointer && !e.IsNil() && (!null || e.Elem().Kind() == reflect.Pointer) {
+				haveAddr = false
+				rv = e
+				continue
+			}
+		}
+		if rv.Kind() != reflect.Pointer {
+			break
+		}
+		if null && rv.CanSet() {
+			break
+		}
+		// Pointer, stored in interface, that points to itself.
+		if rv.Elem().Kind() == reflect.Interface && rv.Elem().Elem().Equal(rv) {
+			rv = rv.Elem()
+			break
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		if rv.Type().NumMethod() > 0 && rv.CanInterface() {
+			if u, ok := rv.Interface().(json.Unmarshaler); ok {
+				return u, nil, reflect.Value{}
+			}
+			if !null {
+				if u, ok := rv.Interface().(encoding.TextUnmarshaler); ok {
+					return nil, u, reflect.Value{}
+				}
+			}
+		}
+		if haveAddr {
+			rv = v0
+			haveAddr = false
+		} else {
+			rv = rv.Elem()
+		}
+	}
+	return nil, nil, rv
+}
+
+// typeError creates error of decoding JSON value into Go value of given
+// type.
+func (v *validator) typeError(value string, t reflect.Type) error {
+	err := &json.UnmarshalTypeError{Value: value, Type: t}
+	if loc := v.instance.String(); loc != "" {
+		return errors.Wrapf(err, "decode %q", loc)
+	}
+	return err
+}
+
+// isEmptyInterface reports whether value is an interface without methods,
+// which holds decoded JSON values as is.
+func isEmptyInterface(rv reflect.Value) bool {
+	return rv.Kind() == reflect.Interface && rv.NumMethod() == 0
+}
+
+var (
+	anySliceType = reflect.TypeFor[[]any]()
+	anyMapType   = reflect.TypeFor[map[string]any]()
+)
+
+// decodeType validates value of given type against type-specific keywords
+// and decodes it into rv.
+func (s *Schema) decodeType(v *validator, d *jx.Decoder, tt jx.Type, rv reflect.Value) error {
+	switch tt {
+	case jx.String:
+		str, err := d.StrBytes()
+		if err != nil {
+			return errors.Wrap(err, "parse JSON")
+		}
+		if err := s.validateValueString(v, str); err != nil {
+			return err
+		}
+		return v.decodeString(str, rv)
+	case jx.Number:
+		num, err := d.Num()
+		if err != nil {
+			return errors.Wrap(err, "parse JSON")
+		}
+		if err := s.validateNum(v, num); err != nil {
+			return err
+		}
+		return v.decodeNumber(num, rv)
+	case jx.Null:
+		if err := s.validateNull(v, d); err != nil {
+			return err
+		}
+		switch rv.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice:
+			rv.SetZero()
+		}
+		return nil
+	case jx.Bool:
+		b, err := d.Bool()
+		if err != nil {
+			return errors.Wrap(err, "parse JSON")
+		}
+		if err := s.checkType(v, booleanType); err != nil {
+			return err
+		}
+		switch {
+		case rv.Kind() == reflect.Bool:
+			rv.SetBool(b)
+		case isEmptyInterface(rv):
+			rv.Set(reflect.ValueOf(b))
+		default:
+			return v.typeError("bool", rv.Type())
+		}
+		return nil
+	case jx.Array, jx.Object:
+		if isEmptyInterface(rv) {
+			typ := anyMapType
+			if tt == jx.Array {
+				typ = anySliceType
+			}
+			val := reflect.New(typ).Elem()
+			if err := s.decodeType(v, d, tt, val); err != nil {
+				return err
+			}
+			rv.Set(val)
+			return nil
+		}
+		if tt == jx.Array {
+			return s.decodeArray(v, d, rv)
+		}
+		return s.decodeObject(v, d, rv)
+	default:
+		return errors.Errorf("unexpected type %q", tt)
+	}
+}
+
+func (v *validator) decodeString(str []byte, rv reflect.Value) error {
+	switch {
+	case rv.Kind() == reflect.String:
+		if rv.Type() == jsonNumberType && !isNumber(str) {
+			return errors.Errorf("invalid number literal %q", str)
+		}
+		rv.SetString(string(str))
+	case rv.Kind() == reflect.Slice && isBytes(rv.Type()):
+		b := make([]byte, base64.StdEncoding.DecodedLen(len(str)))
+		n, err := base64.StdEncoding.Decode(b, str)
+		if err != nil {
+			return errors.Wrap(err, "decode base64")
+		}
+		rv.SetBytes(b[:n])
+	case isEmptyInterface(rv):
+		rv.Set(reflect.ValueOf(string(str)))
+	default:
+		return v.typeError("string", rv.Type())
+	}
+	return nil
+}
+
+// isNumber reports whether given string is a valid JSON number.
+func isNumber(str []byte) bool {
+	d := jx.DecodeBytes(str)
+	if d.Next() != jx.Number {
+		return false
+	}
+	num, err := d.Num()
+	return err == nil && len(num) == len(str)
+}
+
+func (v *validator) decodeNumber(num jx.Num, rv reflect.Value) error {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(string(num), 10, 64)
+		if err != nil || rv.OverflowInt(n) {
+			return v.typeError("number "+string(num), rv.Type())
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(string(num), 10, 64)
+		if err != nil || rv.OverflowUint(n) {
+			return v.typeError("number "+string(num), rv.Type())
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(string(num), rv.Type().Bits())
+		if err != nil || rv.OverflowFloat(f) {
+			return v.typeError("number "+string(num), rv.Type())
+		}
+		rv.SetFloat(f)
+	case reflect.String:
+		if rv.Type() != jsonNumberType {
+			return v.typeError("number", rv.Type())
+		}
+		rv.SetString(string(num))
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return v.typeError("number", rv.Type())
+		}
+		f, err := strconv.ParseFloat(string(num), 64)
+		if err != nil {
+			return v.typeError("number "+string(num), rv.Type())
+		}
+		rv.Set(reflect.ValueOf(f))
+	default:
+		return v.typeError("number", rv.Type())
+	}
+	return nil
+}
+
+// decodeItem validates array item and decodes it into rv.
+func (s *Schema) decodeItem(v *validator, d *jx.Decoder, idx int, state *arrayState, rv reflect.Value) error {
+	var raw jx.Raw
+	if s.uniqueItems || s.contains != nil {
+		var err error
+		raw, err = d.Raw()
+		if err != nil {
+			return errors.Wrap(err, "parse JSON")
+		}
+	}
+	if s.uniqueItems {
+		state.items = append(state.items, raw)
+	}
+	if s.contains != nil {
+		s.validateContains(v, idx, state, func(sub *Schema) error {
+			return sub.validateRaw(v, raw)
+		})
+	}
+
+	n := v.keyword.len()
+	defer v.keyword.pop(n)
+
+	sch, err := s.itemSchema(v, idx, state)
+	if err != nil {
+		return err
+	}
+	if sch == nil {
+		sch = emptySchema
+	}
+	if raw != nil {
+		d = jx.GetDecoder()
+		defer jx.PutDecoder(d)
+		d.ResetBytes(raw)
+	}
+	return sch.decode(v, d, rv)
+}
+
+func (s *Schema) decodeArray(v *validator, d *jx.Decoder, rv reflect.Value) error {
+	if err := s.checkType(v, arrayType); err != nil {
+		return err
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+	default:
+		return v.typeError("array", rv.Type())
+	}
+
+	iter, err := d.ArrIter()
+	if err != nil {
+		return errors.Wrap(err, "parse JSON")
+	}
+	var (
+		i     = 0
+		state = arrayState{eval: v.eval}
+	)
+	// Items are different instances.
+	v.eval = nil
+	defer func() {
+		v.eval = state.eval
+	}()
+	for iter.Next() {
+		var elem reflect.Value
+		switch {
+		case rv.Kind() == reflect.Slice:
+			if i >= rv.Cap() {
+				rv.Grow(1)
+			}
+			rv.SetLen(i + 1)
+			elem = rv.Index(i)
+		case i < rv.Len():
+			elem = rv.Index(i)
+		default:
+			// Items beyond array length are dropped.
+		}
+
+		n := v.instance.pushIndex(i)
+		err := s.decodeItem(v, d, i, &state, elem)
+		v.instance.pop(n)
+
+		if err != nil {
+			return err
+		}
+		i++
+	}
+	if err := iter.Err(); err != nil {
+		return errors.Wrap(err, "parse JSON")
+	}
+	if rv.Kind() == reflect.Slice && rv.IsNil() {
+		// Empty array is decoded as empty slice, not nil.
+		rv.Set(reflect.MakeSlice(rv.Type(), 0, 0))
+	}
+
+	return s.validateArrayEnd(v, i, &state)
+}
+
+// decodeObject validates object and decodes it into map or struct.
+func (s *Schema) decodeObject(v *validator, d *jx.Decoder, rv reflect.Value) error {
+	if err := s.checkType(v, objectType); err != nil {
+		return err
+	}
+	var fields []structField
+	switch rv.Kind() {
+	case reflect.Map:
+		if !isMapKey(rv.Type().Key()) {
+			return v.typeError("object", rv.Type())
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMap(rv.Type()))
+		}
+	case reflect.Struct:
+		fields = cachedFields(rv.Type())
+	default:
+		return v.typeError("object", rv.Type())
+	}
+
+	var (
+		// required maps required property name to the name of property
+		// that requires it, or to an empty string, if it is required by
+		// "required" keyword.
+		required map[string]string
+		// Stack-allocated slice.
+		dependent = make([]dependentSchema, 0, 8)
+	)
+	if len(s.required) > 0 || len(s.dependentRequired) > 0 {
+		required = make(map[string]string, len(s.required))
+		for k := range s.required {
+			required[k] = ""
+		}
+	}
+	if s.hasDependent() {
+		if err := d.Capture(func(d *jx.Decoder) error {
+			return d.ObjBytes(func(d *jx.Decoder, key []byte) error {
+				dependent = s.addDependent(required, dependent, key)
+				return d.Skip()
+			})
+		}); err != nil {
+			return errors.Wrap(err, "collect dependent")
+		}
+	}
+	if err := s.validateDependentSchemas(v, dependent, func(sub *Schema) error {
+		return d.Capture(func(d *jx.Decoder) error {
+			return sub.validate(v, d)
+		})
+	}); err != nil {
+		return err
+	}
+
+	multiPass := s.additionalProperties.Set ||
+		len(s.patternProperties) > 0
+
+	eval := v.eval
+	// Property values are different instances.
+	v.eval = nil
+	defer func() {
+		v.eval = eval
+	}()
+
+	iter, err := d.ObjIter()
+	if err != nil {
+		return errors.Wrap(err, "parse JSON")
+	}
+	i := 0
+	for iter.Next() {
+		k := iter.Key()
+		delete(required, string(k))
+
+		if err := s.validatePropertyName(v, k); err != nil {
+			return err
+		}
+		unevaluated := s.markProperty(eval, k)
+
+		var (
+			// elem is a decoded value, zero if property is dropped.
+			elem   reflect.Value
+			quoted bool
+		)
+		switch rv.Kind() {
+		case reflect.Map:
+			elem = reflect.New(rv.Type().Elem()).Elem()
+		default:
+			// Unknown fields are validated and dropped.
+			if f := findField(fields, k); f != nil {
+				fv, err := fieldByIndexAlloc(rv, f.index)
+				if err != nil {
+					return err
+				}
+				elem, quoted = fv, f.quoted
+			}
+		}
+
+		n := v.instance.pushBytes(k)
+		err := func() error {
+			prop, ok := s.properties[string(k)]
+			if !multiPass && !unevaluated && !quoted {
+				if !ok {
+					return emptySchema.decode(v, d, elem)
+				}
+				n := v.keyword.push("properties")
+				v.keyword.pushBytes(k)
+				defer v.keyword.pop(n)
+
+				return prop.decode(v, d, elem)
+			}
+
+			item, err := d.Raw()
+			if err != nil {
+				return errors.Wrap(err, "parse JSON")
+			}
+			apply := func(sub *Schema) error {
+				return sub.validateRaw(v, item)
+			}
+			switch {
+			case unevaluated:
+				err = s.validateUnevaluatedProperty(v, k, apply)
+			case multiPass || ok:
+				err = s.validateProperty(v, k, apply)
+			}
+			if err != nil {
+				return err
+			}
+			if quoted {
+				return v.decodeQuoted(item, elem)
+			}
+
+			d := jx.GetDecoder()
+			defer jx.PutDecoder(d)
+			d.ResetBytes(item)
+			return emptySchema.decode(v, d, elem)
+		}()
+		if err == nil && rv.Kind() == reflect.Map {
+			err = v.setMapIndex(rv, k, elem)
+		}
+		v.instance.pop(n)
+
+		if err != nil {
+			return err
+		}
+		i++
+	}
+	if err := iter.Err(); err != nil {
+		return errors.Wrap(err, "parse JSON")
+	}
+
+	return s.validateObjectEnd(v, i, required)
+}
+
+// findField returns struct field, matching given key.
+//
+// Like encoding/json, exact match is preferred, otherwise key is matched
+// case-insensitively.
+func findField(fields []structField, key []byte) *structField {
+	var folded *structField
+	for i := range fields {
+		f := &fields[i]
+		if f.name == string(key) {
+			return f
+		}
+		if folded == nil && bytes.EqualFold([]byte(f.name), key) {
+			folded = f
+		}
+	}
+	return folded
+}
+
+// fieldByIndexAlloc returns nested field by index, allocating nil
+// embedded pointers.
+func fieldByIndexAlloc(rv reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}, errors.Errorf("cannot set embedded pointer to unexported struct %s", rv.Type().Elem())
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, nil
+}
+
+// decodeQuoted decodes value of field with ",string" option.
+func (v *validator) decodeQuoted(data []byte, rv reflect.Value) error {
+	d := jx.DecodeBytes(data)
+	if d.Next() == jx.Null {
+		return nil
+	}
+	str, err := d.StrBytes()
+	if err != nil {
+		return errors.Errorf("invalid use of ,string struct tag, trying to decode %s into %s", data, rv.Type())
+	}
+	switch jx.DecodeBytes(str).Next() {
+	case jx.String, jx.Number, jx.Bool, jx.Null:
+	default:
+		return errors.Errorf("invalid use of ,string struct tag, trying to decode %q into %s", str, rv.Type())
+	}
+	return emptySchema.decode(v, jx.DecodeBytes(str), rv)
+}
+
+// isMapKey reports whether map with given key type can be decoded.
+func isMapKey(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	default:
+		return false
+	}
+}
+
+// setMapIndex sets decoded value of the map element with given key,
+// converting key like encoding/json does.
+func (v *validator) setMapIndex(m reflect.Value, key []byte, elem reflect.Value) error {
+	kt := m.Type().Key()
+	kv := reflect.New(kt).Elem()
+	switch {
+	case reflect.PointerTo(kt).Implements(textUnmarshalerType):
+		if err := kv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText(key); err != nil {
+			return err
+		}
+	case kt.Kind() == reflect.String:
+		kv.SetString(string(key))
+	default:
+		if err := v.decodeNumber(jx.Num(key), kv); err != nil {
+			return err
+		}
+	}
+	m.SetMapIndex(kv, elem)
+	return nil
+}
@@ -0,0 +1,251 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type unmarshalBase struct {
+	ID int `json:"id"`
+}
+
+type unmarshalConfig struct {
+	unmarshalBase
+	*UnmarshalMeta
+	Name     string            `json:"name"`
+	Port     uint16            `json:"port"`
+	Ratio    float64           `json:"ratio"`
+	Count    int64             `json:"count,string"`
+	Enabled  *bool             `json:"enabled,omitempty"`
+	Tags     []string          `json:"tags"`
+	Pair     [2]int            `json:"pair"`
+	Payload  []byte            `json:"payload,omitempty"`
+	Addr     netip.Addr        `json:"addr,omitzero"`
+	Timeout  time.Time         `json:"timeout,omitzero"`
+	Limits   map[int]float32   `json:"limits,omitempty"`
+	Extra    json.RawMessage   `json:"extra,omitempty"`
+	Any      any               `json:"any,omitempty"`
+	Number   json.Number       `json:"number,omitempty"`
+	Nested   []unmarshalBase   `json:"nested,omitempty"`
+	Untagged string            //nolint:tagliatelle
+	Raw      map[string][]byte `json:"raw,omitempty"`
+}
+
+// UnmarshalMeta is exported, so embedded pointer can be allocated.
+type UnmarshalMeta struct {
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+const unmarshalSchema = `{
+	"type": "object",
+	"required": ["id", "name", "port"],
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"labels": {"additionalProperties": {"type": "string", "pattern": "^[a-z]+$"}},
+		"name": {"type": "string", "minLength": 1},
+		"port": {"type": "integer", "maximum": 1024},
+		"ratio": {"type": "number", "exclusiveMaximum": 1},
+		"count": {"type": "string", "pattern": "^[0-9]+$"},
+		"enabled": {"const": true},
+		"tags": {"type": "array", "uniqueItems": true, "items": {"enum": ["a", "b", "c"]}},
+		"pair": {"type": "array", "items": {"minimum": 0}},
+		"payload": {"type": "string", "contentEncoding": "base64"},
+		"addr": {"enum": ["127.0.0.1"]},
+		"timeout": {"type": "string", "format": "date-time"},
+		"limits": {"propertyNames": {"pattern": "^[0-9]+$"}, "additionalProperties": {"multipleOf": 0.5}},
+		"extra": {"type": "object", "maxProperties": 1},
+		"any": {"type": ["null", "integer", "object"]},
+		"number": {"maximum": 1e3},
+		"nested": {"items": {"properties": {"id": {"maximum": 2}}}},
+		"raw": {"additionalProperties": {"type": "string"}}
+	},
+	"patternProperties": {"^x-": {"type": "string"}},
+	"dependencies": {"ratio": ["count"]}
+}`
+
+func TestUnmarshal(t *testing.T) {
+	sch, err := Parse([]byte(unmarshalSchema))
+	require.NoError(t, err)
+
+	for i, tt := range []struct {
+		data  string
+		valid bool
+	}{
+		{`{"id": 1, "name": "foo", "port": 80}`, true},
+		{`{
+			"id": 1,
+			"labels": {"app": "foo"},
+			"name": "foo",
+			"port": 80,
+			"ratio": 0.5,
+			"count": "10",
+			"enabled": true,
+			"tags": ["a", "b"],
+			"pair": [1, 2, 3],
+			"payload": "Zm9v",
+			"addr": "127.0.0.1",
+			"timeout": "2024-01-01T00:00:00Z",
+			"limits": {"1": 1.5, "10": 2},
+			"extra": {"a": [1, 2]},
+			"any": {"a": [1, "b", null, true]},
+			"number": 1e2,
+			"nested": [{"id": 1}, {"id": 2}],
+			"raw": {"a": "", "b": "Zm9v"},
+			"x-unknown": "foo",
+			"unknown": [1, 2, 3],
+			"untagged": "case-insensitive"
+		}`, true},
+		{`{"id": 1, "name": "foo", "port": 80, "enabled": null, "tags": null, "any": null}`, false},
+		{`{"id": 1, "name": "foo", "port": 80, "tags": null, "any": null}`, false},
+		{`{"id": 1, "name": "foo", "port": 80, "any": null}`, true},
+
+		{`{"id": 0, "name": "foo", "port": 80}`, false},
+		{`{"id": 1, "name": "", "port": 80}`, false},
+		{`{"id": 1, "name": "foo"}`, false},
+		{`{"id": 1, "name": "foo", "port": 8080}`, false},
+		{`{"id": 1, "name": "foo", "port": 80, "labels": {"app": "Foo"}}`, false},
+		{`{"id": 1, "name": "foo", "port": 80, "ratio": 0.5}`, false},
+		{`{"id": 1, "name": "foo", "port": 80, "count": "-1"}`, false},
+		{`{"id": 1, "name": "foo", "port": 80, "tags": ["a", "a"]}`, false},
+		{`{"id": 1, "name": "foo", "port": 80, "pair": [-1, 2]}`, false},
+		{`{"id": 1, "name": "foo", "port": 80, "addr": "::1"}`, false},
+		{`{"id": 1, "name": "foo", "port": 80, "limits": {"1": 0.1}}`, false},
+		{`{"id": 1, "name": "foo", "port": 80, "any": 1.5}`, false},
+		{`{"id": 1, "name": "foo", "port": 80, "number": 1e4}`, false},
+		{`{"id": 1, "name": "foo", "port": 80, "nested": [{"id": 1}, {"id": 3}]}`, false},
+		{`{"id": 1, "name": "foo", "port": 80, "x-unknown": 1}`, false},
+		{`{"id": 1, "name": "foo", "port": 80, "raw": {"a": 1}}`, false},
+		{`[]`, false},
+	} {
+		data := []byte(tt.data)
+
+		expected := unmarshalConfig{Name: "initial"}
+		if tt.valid {
+			require.NoError(t, sch.Validate(data), "test %d", i)
+			require.NoError(t, json.Unmarshal(data, &expected), "test %d", i)
+		} else {
+			require.Error(t, sch.Validate(data), "test %d", i)
+		}
+
+		actual := unmarshalConfig{Name: "initial"}
+		err := Unmarshal(sch, data, &actual)
+		if !tt.valid {
+			require.Error(t, err, "test %d", i)
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr, "test %d", i)
+		} else {
+			require.NoError(t, err, "test %d", i)
+		}
+		require.Equal(t, expected, actual, "test %d", i)
+	}
+}
+
+func TestUnmarshalDecodeError(t *testing.T) {
+	sch, err := Parse([]byte(`{}`))
+	require.NoError(t, err)
+
+	for i, tt := range []struct {
+		data   string
+		target any
+	}{
+		{`"foo"`, new(int)},
+		{`1.5`, new(int)},
+		{`256`, new(uint8)},
+		{`-1`, new(uint)},
+		{`1`, new(string)},
+		{`"1.0.0"`, new(json.Number)},
+		{`{}`, new([]int)},
+		{`[]`, new(map[string]int)},
+		{`{"a": "b"}`, new(map[int]string)},
+		{`{"true": "a"}`, new(map[bool]string)},
+		{`{"a": true}`, new(struct{ A int })},
+		{`"not base64"`, new([]byte)},
+		{`"foo"`, new(netip.Addr)},
+		{`1`, new(netip.Addr)},
+		{`{"A": 1}`, new(struct {
+			A int `json:",string"`
+		})},
+		{`{"A": "[1]"}`, new(struct {
+			A []int `json:",string"`
+		})},
+		{`1 1`, new(int)},
+		{`[1, `, new([]int)},
+	} {
+		_, jsonErr := json.Marshal(tt.target)
+		require.NoError(t, jsonErr)
+		require.Error(t, json.Unmarshal([]byte(tt.data), tt.target), "test %d: %s", i, tt.data)
+		require.Error(t, Unmarshal(sch, []byte(tt.data), tt.target), "test %d: %s", i, tt.data)
+	}
+
+	for _, target := range []any{nil, 1, (*int)(nil)} {
+		var invalidErr *json.InvalidUnmarshalError
+		require.ErrorAs(t, Unmarshal(sch, []byte(`1`), target), &invalidErr)
+	}
+}
+
+func TestUnmarshalValidationFirst(t *testing.T) {
+	sch, err := Parse([]byte(`{"properties": {"a": {"type": "string"}}}`))
+	require.NoError(t, err)
+
+	// Validation error is reported instead of decoding error.
+	var v struct {
+		A int `json:"a"`
+	}
+	err = Unmarshal(sch, []byte(`{"a": 1}`), &v)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Equal(t, "/properties/a/type", validationErr.KeywordLocation)
+	require.Zero(t, v.A)
+}
+
+func TestBenchSuiteUnmarshal(t *testing.T) {
+	for _, s := range collectBench(t) {
+		s := s
+		t.Run(s.Name, func(t *testing.T) {
+			if s.Skip {
+				t.Skip("Unsupported yet")
+			}
+
+			for _, data := range s.Data {
+				data := data
+				t.Run(data.Name, func(t *testing.T) {
+					var val, expected any
+					require.NoError(t, Unmarshal(s.Schema, data.Data, &val))
+					require.NoError(t, json.Unmarshal(data.Data, &expected))
+					require.Equal(t, expected, val)
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	for _, s := range collectBench(b) {
+		s := s
+		b.Run(s.Name, func(b *testing.B) {
+			if s.Skip {
+				b.Skip("Unsupported yet")
+			}
+
+			for _, data := range s.Data {
+				data := data
+				b.Run(data.Name, func(b *testing.B) {
+					b.ReportAllocs()
+					b.SetBytes(int64(len(data.Data)))
+					b.ResetTimer()
+
+					for i := 0; i < b.N; i++ {
+						var val any
+						if err := Unmarshal(s.Schema, data.Data, &val); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		})
+	}
+}
@@ -1,8 +1,16 @@
 package jsonschema
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
+	"mime"
+	"sort"
+	"strconv"
+	"sync"
 	"unicode/utf8"
 
 	"github.com/go-faster/errors"
@@ -11,22 +19,338 @@ import (
 	"github.com/tdakkota/jsonschema/internal/jsonequal"
 )
 
+// validator holds validation state.
+type validator struct {
+	// all is set if validator should collect all errors instead of
+	// stopping at the first one.
+	all bool
+	// instance is a location of the validated value.
+	instance pointer
+	// keyword is a location of the current schema, relative to the root schema.
+	keyword pointer
+	// trace records evaluation results, if set.
+	trace *outputTrace
+	// eval collects properties and items, evaluated by the current
+	// schema, if set.
+	//
+	// Used by "unevaluatedProperties" and "unevaluatedItems".
+	eval *evaluated
+	// scope is a dynamic scope, a list of entered schema resources.
+	//
+	// Used by "$recursiveRef".
+	scope []*Schema
+	// annotations collects annotations of successfully validated
+	// schemas, if set.
+	annotations *[]Annotation
+	// stream is set if data is read from io.Reader.
+	//
+	// Buffer of streaming decoder is reused, so raw values must be
+	// copied to be retained, and decoder can't be rewound by Capture.
+	stream bool
+	// valueDepth is a depth of the validated Go value.
+	//
+	// Used by ValidateValue to detect cycles.
+	valueDepth int
+	// valueSeen is a set of Go values on the current instance path,
+	// tracked after startDetectingCyclesAfter depth.
+	valueSeen map[valueKey]struct{}
+}
+
+// validatorPool reuses validators to reuse their location buffers.
+var validatorPool = sync.Pool{
+	New: func() any {
+		return &validator{}
+	},
+}
+
+// getValidator returns validator with empty state from the pool.
+func getValidator() *validator {
+	return validatorPool.Get().(*validator)
+}
+
+// putValidator resets given validator and puts it to the pool.
+func putValidator(v *validator) {
+	v.reset()
+	validatorPool.Put(v)
+}
+
+// reset resets validator state, keeping location buffers.
+func (v *validator) reset() {
+	keyword, instance := v.keyword, v.instance
+	keyword.reset()
+	instance.reset()
+	*v = validator{
+		keyword:  keyword,
+		instance: instance,
+	}
+}
+
+// locate validates value by given function without tracking locations
+// and, if value is invalid, validates it again to locate the error.
+//
+// Locations are needed only by validation errors, so valid values do not
+// pay for them. Validation must be repeatable and stop at the first error.
+func (v *validator) locate(validate func(v *validator) error) error {
+	v.keyword.off, v.instance.off = true, true
+	err := validate(v)
+	if _, ok := asValidationErrors(err); !ok {
+		return err
+	}
+	v.reset()
+	return validate(v)
+}
+
+// evaluated is a set of evaluated properties and items of a single
+// instance.
+type evaluated struct {
+	props map[string]struct{}
+	// items is a number of evaluated leading items.
+	items int
+	// allItems is set if all items are evaluated.
+	allItems bool
+	// indices is a set of evaluated items, matching "contains".
+	indices map[int]struct{}
+}
+
+func (e *evaluated) addProp(key []byte) {
+	if e.props == nil {
+		e.props = map[string]struct{}{}
+	}
+	e.props[string(key)] = struct{}{}
+}
+
+func (e *evaluated) hasProp(key []byte) bool {
+	if e == nil {
+		return false
+	}
+	_, ok := e.props[string(key)]
+	return ok
+}
+
+func (e *evaluated) addItem(idx int) {
+	if e.indices == nil {
+		e.indices = map[int]struct{}{}
+	}
+	e.indices[idx] = struct{}{}
+}
+
+func (e *evaluated) hasItem(idx int) bool {
+	if e == nil {
+		return false
+	}
+	if e.allItems || idx < e.items {
+		return true
+	}
+	_, ok := e.indices[idx]
+	return ok
+}
+
+// merge adds evaluated properties and items of other to e.
+func (e *evaluated) merge(other *evaluated) {
+	for k := range other.props {
+		if e.props == nil {
+			e.props = make(map[string]struct{}, len(other.props))
+		}
+		e.props[k] = struct{}{}
+	}
+	for idx := range other.indices {
+		e.addItem(idx)
+	}
+	e.items = max(e.items, other.items)
+	e.allItems = e.allItems || other.allItems
+}
+
+// report adds given error to the list and reports whether validation
+// should be stopped.
+func (v *validator) report(errs *errorList, err error) (stop bool) {
+	errs.add(err)
+	return !v.all
+}
+
+// fail creates validation error of given keyword of schema s.
+//
+// If keyword is empty, error is related to the schema itself.
+func (v *validator) fail(s *Schema, keyword string, expected, actual any, format string, args ...any) *ValidationError {
+	keywordLoc, absLoc := v.keyword.String(), s.loc
+	if keyword != "" {
+		keywordLoc += "/" + keyword
+		absLoc += "/" + keyword
+	}
+	e := &ValidationError{
+		InstanceLocation:        v.instance.String(),
+		KeywordLocation:         keywordLoc,
+		AbsoluteKeywordLocation: absLoc,
+		Keyword:                 keyword,
+		Expected:                expected,
+		Actual:                  actual,
+		Message:                 fmt.Sprintf(format, args...),
+	}
+	if t := v.trace; t != nil {
+		t.fail(e)
+	}
+	return e
+}
+
+// failCauses creates validation error of composition keyword.
+func (v *validator) failCauses(s *Schema, keyword string, causes errorList, msg string) error {
+	list, ok := asValidationErrors(causes.err())
+	if !ok {
+		// Non-validation error, e.g. syntax error.
+		for _, err := range causes {
+			if _, ok := asValidationErrors(err); !ok {
+				return err
+			}
+		}
+	}
+	e := v.fail(s, keyword, nil, nil, "%s", msg)
+	e.Causes = list
+	return e
+}
+
+// applier validates the current instance against given subschema.
+//
+// Used by keywords, that apply subschemas to the same instance, like
+// "allOf" or "$ref".
+type applier func(s *Schema) error
+
+// valid reports whether the current instance is valid against given
+// schema.
+//
+// Only validity matters, so validation stops at the first error.
+func (v *validator) valid(s *Schema, apply applier) bool {
+	all := v.all
+	v.all = false
+	err := apply(s)
+	v.all = all
+	return err == nil
+}
+
 // Validate validates given data.
+//
+// Validation stops at the first error, use ValidateAll to get all of them.
+// If data is a valid JSON, returned error is *ValidationError.
 func (s *Schema) Validate(data []byte) error {
+	v := getValidator()
+	defer putValidator(v)
+	return v.locate(func(v *validator) error {
+		return s.validateRaw(v, data)
+	})
+}
+
+// ValidateAll validates given data and collects all validation errors
+// instead of stopping at the first one.
+//
+// If data is a valid JSON, returned error is ValidationErrors.
+func (s *Schema) ValidateAll(data []byte) error {
+	v := getValidator()
+	defer putValidator(v)
+	v.all = true
+	return s.validateAll(v, data)
+}
+
+// ValidateReader validates JSON, read from given reader.
+//
+// Unlike Validate, ValidateReader does not buffer the whole data. Only
+// values, validated by keywords, that need more than one pass, like
+// "allOf", "uniqueItems" or "patternProperties", are buffered.
+//
+// Validation stops at the first error. If data is a valid JSON, returned
+// error is *ValidationError.
+func (s *Schema) ValidateReader(r io.Reader) error {
+	v := getValidator()
+	defer putValidator(v)
+	v.stream = true
+
+	d := jx.Decode(r, streamBufSize)
+	return s.validate(v, d)
+}
+
+// streamBufSize is a size of ValidateReader read buffer.
+const streamBufSize = 32 << 10
+
+func (s *Schema) validateAll(v *validator, data []byte) error {
 	d := jx.GetDecoder()
 	defer jx.PutDecoder(d)
-	// TODO: do not stop early, collect errors instead.
+
+	// Check syntax first, so validators may keep going after
+	// the first error without hitting a malformed value.
 	d.ResetBytes(data)
-	return s.validate(d)
+	if err := d.Validate(); err != nil {
+		return errors.Wrap(err, "invalid json")
+	}
+
+	d.ResetBytes(data)
+	if err := s.validate(v, d); err != nil {
+		errs, ok := asValidationErrors(err)
+		if !ok {
+			return err
+		}
+		return errs
+	}
+	return nil
+}
+
+func (s *Schema) validateRaw(v *validator, data []byte) error {
+	d := jx.GetDecoder()
+	defer jx.PutDecoder(d)
+	d.ResetBytes(data)
+	return s.validate(v, d)
+}
+
+func (s *Schema) validate(v *validator, d *jx.Decoder) error {
+	if t := v.trace; t != nil {
+		n := t.enter(v, s)
+		err := s.validate1(v, d)
+		t.leave(n, err)
+		return err
+	}
+	return s.validate1(v, d)
+}
+
+func (s *Schema) validate1(v *validator, d *jx.Decoder) error {
+	// Functions on the validation path avoid defer, it is not free for
+	// functions with many returns.
+	if ref := s.ref; ref != nil && s.refOverride {
+		n := v.keyword.push("$ref")
+		err := ref.validate(v, d)
+		v.keyword.pop(n)
+		return err
+	}
+
+	st := s.enter(v)
+	err := s.validateKeywords(v, d)
+	s.leave(v, st, err)
+	return err
 }
 
-func (s *Schema) validate(d *jx.Decoder) error {
+// validateKeywords validates value against keywords of the schema.
+func (s *Schema) validateKeywords(v *validator, d *jx.Decoder) error {
 	tt := d.Next()
 	if tt == jx.Invalid {
 		return errors.Wrap(d.Validate(), "invalid json")
 	}
 
-	if len(s.enum) > 0 || len(s.allOf) > 0 || len(s.oneOf) > 0 || len(s.anyOf) > 0 || s.not != nil {
+	if s.never {
+		if err := d.Skip(); err != nil {
+			return err
+		}
+		return v.fail(s, "", false, nil, "false schema does not allow any value")
+	}
+
+	var errs errorList
+	if s.streamsRef(tt) {
+		// Validate the value against "$ref" without buffering.
+		if err := s.validateRef(v, func(sub *Schema) error {
+			return sub.validate(v, d)
+		}); err != nil && v.report(&errs, err) {
+			return errs.err()
+		}
+		if err := s.checkType(v, valueType(tt)); err != nil {
+			errs.add(err)
+		}
+		return errs.err()
+	}
+	if s.hasInPlace() {
 		data, err := d.Raw()
 		if err != nil {
 			return errors.Wrap(err, "invalid json")
@@ -36,47 +360,242 @@ func (s *Schema) validate(d *jx.Decoder) error {
 		defer jx.PutDecoder(d)
 		d.ResetBytes(data)
 
-		if err := s.validateEnum(data); err != nil {
-			return errors.Wrap(err, "enum")
-		}
-		if err := s.validateAllOf(data); err != nil {
-			return errors.Wrap(err, "allOf")
-		}
-		if err := s.validateOneOf(data); err != nil {
-			return errors.Wrap(err, "oneOf")
-		}
-		if err := s.validateAnyOf(data); err != nil {
-			return errors.Wrap(err, "anyOf")
-		}
-		if err := s.validateNot(data); err != nil {
-			return errors.Wrap(err, "not")
+		if err := s.validateInPlace(v, data, func(sub *Schema) error {
+			return sub.validateRaw(v, data)
+		}); err != nil && v.report(&errs, err) {
+			return errs.err()
 		}
 	}
 
-	var err error
+	if err := s.validateType(v, d, tt); err != nil {
+		errs.add(err)
+	}
+	return errs.err()
+}
+
+// validateType validates value of given type against type-specific
+// keywords.
+func (s *Schema) validateType(v *validator, d *jx.Decoder, tt jx.Type) error {
 	switch tt {
 	case jx.String:
-		err = s.validateString(d)
+		return s.validateString(v, d)
 	case jx.Number:
-		err = s.validateNumber(d)
+		return s.validateNumber(v, d)
 	case jx.Null:
-		err = s.validateNull(d)
+		return s.validateNull(v, d)
 	case jx.Bool:
-		err = s.validateBool(d)
+		return s.validateBool(v, d)
 	case jx.Array:
-		err = s.validateArray(d)
+		return s.validateArray(v, d)
 	case jx.Object:
-		err = s.validateObject(d)
+		return s.validateObject(v, d)
 	default:
 		panic(fmt.Sprintf("unreachable: %q", tt))
 	}
-	if err != nil {
-		return errors.Wrap(err, tt.String())
+}
+
+// enterState is a validator state, saved by enter.
+type enterState struct {
+	// scoped is set if schema is added to the dynamic scope.
+	scoped bool
+	// outer and local are evaluated properties and items of the parent
+	// and the entered schema.
+	outer, local *evaluated
+	// mark is a number of annotations, collected before the schema.
+	mark int
+}
+
+// enter prepares validator to validate the schema.
+//
+// Must be followed by leave.
+func (s *Schema) enter(v *validator) (st enterState) {
+	if s.resource {
+		v.scope = append(v.scope, s)
+		st.scoped = true
 	}
-	return nil
+	if outer := v.eval; outer != nil || s.unevaluatedProperties != nil || s.unevaluatedItems != nil {
+		st.outer, st.local = outer, &evaluated{}
+		v.eval = st.local
+	}
+	if list := v.annotations; list != nil {
+		st.mark = len(*list)
+		s.annotate(v)
+		if t := v.trace; t != nil {
+			t.annotate((*list)[st.mark:])
+		}
+	}
+	return st
 }
 
-func (s *Schema) validateEnum(data []byte) error {
+// leave restores validator state, saved by enter.
+//
+// Annotations and evaluated properties and items of failed schema are
+// dropped.
+func (s *Schema) leave(v *validator, st enterState, err error) {
+	if list := v.annotations; list != nil && err != nil {
+		*list = (*list)[:st.mark]
+	}
+	if st.local != nil {
+		v.eval = st.outer
+		if st.outer != nil && err == nil {
+			st.outer.merge(st.local)
+		}
+	}
+	if st.scoped {
+		v.scope = v.scope[:len(v.scope)-1]
+	}
+}
+
+// hasInPlace reports whether schema has keywords, validated by
+// validateInPlace.
+func (s *Schema) hasInPlace() bool {
+	return s.ref != nil || s.hasInPlaceExceptRef()
+}
+
+// hasInPlaceExceptRef is like hasInPlace, but ignores "$ref".
+func (s *Schema) hasInPlaceExceptRef() bool {
+	return len(s.enum) > 0 || s.constant != nil ||
+		len(s.allOf) > 0 || len(s.oneOf) > 0 || len(s.anyOf) > 0 || s.not != nil ||
+		s.ifSchema != nil || s.recursiveRef != nil || s.dynamicRef != nil
+}
+
+// streamsRef reports whether "$ref" is the only keyword, that reads the
+// value of given type, so the value may be passed to the referenced
+// schema without buffering.
+func (s *Schema) streamsRef(tt jx.Type) bool {
+	if s.ref == nil || s.hasInPlaceExceptRef() {
+		return false
+	}
+	switch tt {
+	case jx.String:
+		return !s.hasStringChecks()
+	case jx.Number:
+		// Integers are distinguished by the value.
+		return !s.hasNumberLimits() && s.types.has(numberType)
+	case jx.Array:
+		return !s.hasArrayChecks()
+	case jx.Object:
+		return !s.hasObjectChecks()
+	default:
+		return true
+	}
+}
+
+// valueType returns type of the value with given JSON type.
+//
+// Numbers are reported as numberType.
+func valueType(tt jx.Type) typeSet {
+	switch tt {
+	case jx.String:
+		return stringType
+	case jx.Number:
+		return numberType
+	case jx.Null:
+		return nullType
+	case jx.Bool:
+		return booleanType
+	case jx.Array:
+		return arrayType
+	default:
+		return objectType
+	}
+}
+
+// validateInPlace validates keywords, that compare the whole instance or
+// apply subschemas to it.
+//
+// data is a JSON value of the instance, apply validates it against
+// subschema.
+func (s *Schema) validateInPlace(v *validator, data []byte, apply applier) error {
+	var errs errorList
+	if err := s.validateRef(v, apply); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+	if err := s.validateRecursiveRef(v, apply); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+	if err := s.validateDynamicRef(v, apply); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+	if err := s.validateEnum(v, data); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+	if err := s.validateConst(v, data); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+	if err := s.validateAllOf(v, apply); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+	if err := s.validateOneOf(v, apply); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+	if err := s.validateAnyOf(v, apply); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+	if err := s.validateNot(v, apply); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+	if err := s.validateIf(v, apply); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+	return errs.err()
+}
+
+func (s *Schema) validateRef(v *validator, apply applier) error {
+	if s.ref == nil {
+		return nil
+	}
+
+	n := v.keyword.push("$ref")
+	defer v.keyword.pop(n)
+	return apply(s.ref)
+}
+
+func (s *Schema) validateRecursiveRef(v *validator, apply applier) error {
+	target := s.recursiveRef
+	if target == nil {
+		return nil
+	}
+
+	if target.recursiveAnchor {
+		// Use the outermost resource of the dynamic scope, that allows
+		// recursive extension.
+		for _, r := range v.scope {
+			if r.recursiveAnchor {
+				target = r
+				break
+			}
+		}
+	}
+
+	n := v.keyword.push("$recursiveRef")
+	defer v.keyword.pop(n)
+	return apply(target)
+}
+
+func (s *Schema) validateDynamicRef(v *validator, apply applier) error {
+	target := s.dynamicRef
+	if target == nil {
+		return nil
+	}
+
+	if name := s.dynamicRefAnchor; name != "" {
+		// Use the outermost resource of the dynamic scope, that defines
+		// the same dynamic anchor.
+		for _, r := range v.scope {
+			if a, ok := r.dynamicAnchors[name]; ok {
+				target = a
+				break
+			}
+		}
+	}
+
+	n := v.keyword.push("$dynamicRef")
+	defer v.keyword.pop(n)
+	return apply(target)
+}
+
+func (s *Schema) validateEnum(v *validator, data []byte) error {
 	if len(s.enum) == 0 {
 		return nil
 	}
@@ -94,121 +613,264 @@ func (s *Schema) validateEnum(data []byte) error {
 			return nil
 		}
 	}
-	return errors.Errorf("%q is not present in enum", data)
+	return v.fail(s, "enum", s.enum, copyRaw(data), "value %s is not present in enum", data)
 }
 
-func (s *Schema) validateAllOf(data []byte) error {
-	d := jx.GetDecoder()
-	defer jx.PutDecoder(d)
+func (s *Schema) validateConst(v *validator, data []byte) error {
+	if s.constant == nil {
+		return nil
+	}
+
+	ok, err := jsonequal.Equal(s.constant, data)
+	if err != nil {
+		return errors.Wrap(err, "compare")
+	}
+	if ok {
+		return nil
+	}
+	return v.fail(s, "const", s.constant, copyRaw(data), "value %s is not equal to %s", data, s.constant)
+}
 
+func (s *Schema) validateAllOf(v *validator, apply applier) error {
+	var errs errorList
 	for i, schema := range s.allOf {
-		d.ResetBytes(data)
-		if err := schema.validate(d); err != nil {
-			return errors.Wrapf(err, "[%d]", i)
+		n := v.keyword.push("allOf")
+		v.keyword.pushIndex(i)
+		err := apply(schema)
+		v.keyword.pop(n)
+
+		if err != nil && v.report(&errs, err) {
+			break
 		}
 	}
-	return nil
+	return errs.err()
 }
 
-func (s *Schema) validateOneOf(data []byte) error {
+func (s *Schema) validateOneOf(v *validator, apply applier) error {
 	if len(s.oneOf) == 0 {
 		return nil
 	}
 
-	d := jx.GetDecoder()
-	defer jx.PutDecoder(d)
+	var (
+		matched []int
+		causes  errorList
+		mark    = v.trace.mark()
+	)
+	for i, schema := range s.oneOf {
+		n := v.keyword.push("oneOf")
+		v.keyword.pushIndex(i)
+		err := apply(schema)
+		v.keyword.pop(n)
 
-	counter := 0
-	for _, schema := range s.oneOf {
-		d.ResetBytes(data)
-		if err := schema.validate(d); err == nil {
-			if counter != 0 {
-				return errors.New("must match exactly once")
-			}
-			counter++
+		if err != nil {
+			causes.add(err)
+			continue
+		}
+		matched = append(matched, i)
+		if len(matched) > 1 {
+			return v.fail(s, "oneOf", 1, matched, "must match exactly once, but matches %v", matched)
 		}
 	}
-	if counter != 0 {
+	if len(matched) != 0 {
+		v.trace.discard(mark)
 		return nil
 	}
-	return errors.New("must match at least once")
+	return v.failCauses(s, "oneOf", causes, "must match at least once")
 }
 
-func (s *Schema) validateAnyOf(data []byte) error {
+func (s *Schema) validateAnyOf(v *validator, apply applier) error {
 	if len(s.anyOf) == 0 {
 		return nil
 	}
 
-	d := jx.GetDecoder()
-	defer jx.PutDecoder(d)
+	var (
+		matched bool
+		causes  errorList
+		mark    = v.trace.mark()
+	)
+	for i, schema := range s.anyOf {
+		n := v.keyword.push("anyOf")
+		v.keyword.pushIndex(i)
+		err := apply(schema)
+		v.keyword.pop(n)
 
-	for _, schema := range s.anyOf {
-		d.ResetBytes(data)
-		if err := schema.validate(d); err == nil {
-			return nil
+		if err == nil {
+			matched = true
+			if v.eval == nil && v.annotations == nil {
+				break
+			}
+			// Every matching subschema evaluates properties and items and
+			// produces annotations.
+			continue
 		}
+		causes.add(err)
+	}
+	if matched {
+		v.trace.discard(mark)
+		return nil
 	}
-	return errors.New("must match at least once")
+	return v.failCauses(s, "anyOf", causes, "must match at least once")
 }
 
-func (s *Schema) validateNot(data []byte) error {
-	if s.not != nil {
-		if err := s.not.Validate(data); err == nil {
-			return errors.New("must not match")
-		}
+func (s *Schema) validateNot(v *validator, apply applier) error {
+	if s.not == nil {
+		return nil
+	}
+
+	n, eval := v.keyword.push("not"), v.eval
+	mark := v.trace.mark()
+	// Annotations of "not" are always dropped.
+	v.eval = nil
+	valid := v.valid(s.not, apply)
+	v.eval = eval
+	// Result of subschema is inverted, so its errors are never reported.
+	v.trace.discard(mark)
+	v.keyword.pop(n)
+
+	if valid {
+		return v.fail(s, "not", nil, nil, "must not match")
 	}
 	return nil
 }
 
-func (s *Schema) checkType(t typeSet) error {
+func (s *Schema) validateIf(v *validator, apply applier) error {
+	if s.ifSchema == nil {
+		return nil
+	}
+
+	n := v.keyword.push("if")
+	mark := v.trace.mark()
+	valid := v.valid(s.ifSchema, apply)
+	// "if" result only selects the branch, so its errors are never reported.
+	v.trace.discard(mark)
+	v.keyword.pop(n)
+
+	keyword, branch := "then", s.thenSchema
+	if !valid {
+		keyword, branch = "else", s.elseSchema
+	}
+	if branch == nil {
+		return nil
+	}
+
+	n = v.keyword.push(keyword)
+	defer v.keyword.pop(n)
+	return apply(branch)
+}
+
+func (s *Schema) checkType(v *validator, t typeSet) error {
 	if !s.types.has(t) {
-		return errors.New("type is not allowed")
+		return v.fail(s, "type", s.types.names(), t.String(), "%s is not allowed", t)
 	}
 	return nil
 }
 
-func (s *Schema) skipType(d *jx.Decoder, t typeSet) error {
-	if err := s.checkType(t); err != nil {
+func (s *Schema) skipType(v *validator, d *jx.Decoder, t typeSet) error {
+	// Skip value first: validation may continue after type mismatch.
+	if err := d.Skip(); err != nil {
 		return err
 	}
-	return d.Skip()
+	return s.checkType(v, t)
 }
 
-func (s *Schema) validateString(d *jx.Decoder) error {
-	if err := s.checkType(stringType); err != nil {
-		return err
+func (s *Schema) validateString(v *validator, d *jx.Decoder) error {
+	var errs errorList
+	if err := s.checkType(v, stringType); err != nil && v.report(&errs, err) {
+		return errs.err()
 	}
 
-	if !(s.format != "" || s.minLength.IsSet() || s.maxLength.IsSet() || s.pattern != nil) {
-		return d.Skip()
+	if !s.hasStringChecks() {
+		if err := d.Skip(); err != nil {
+			return err
+		}
+		return errs.err()
 	}
 
 	str, err := d.StrBytes()
 	if err != nil {
 		return errors.Wrap(err, "parse JSON")
 	}
-	if s.format != "" {
-		panic("unreachable")
+	if err := s.validateStr(v, str); err != nil {
+		errs.add(err)
+	}
+	return errs.err()
+}
+
+// hasStringChecks reports whether schema has string keywords, except
+// "type".
+func (s *Schema) hasStringChecks() bool {
+	return s.formatCheck != nil || s.contentCheck ||
+		s.minLength.IsSet() || s.maxLength.IsSet() || s.pattern != nil
+}
+
+// validateStr validates string value against string keywords.
+func (s *Schema) validateStr(v *validator, str []byte) error {
+	var errs errorList
+	if check := s.formatCheck; check != nil {
+		if err := check(str); err != nil &&
+			v.report(&errs, v.fail(s, "format", s.format, string(str),
+				"%q is not valid %s: %s", str, s.format, err)) {
+			return errs.err()
+		}
 	}
 	if s.minLength.IsSet() || s.maxLength.IsSet() {
 		count := utf8.RuneCount(str)
-		if s.minLength.IsSet() && count < int(s.minLength) {
-			return errors.Errorf("length is smaller than %d", s.minLength)
+		if s.minLength.IsSet() && count < int(s.minLength) &&
+			v.report(&errs, v.fail(s, "minLength", int(s.minLength), count,
+				"length %d is smaller than %d", count, s.minLength)) {
+			return errs.err()
 		}
-		if s.maxLength.IsSet() && count > int(s.maxLength) {
-			return errors.Errorf("length is bigger than %d", s.maxLength)
+		if s.maxLength.IsSet() && count > int(s.maxLength) &&
+			v.report(&errs, v.fail(s, "maxLength", int(s.maxLength), count,
+				"length %d is bigger than %d", count, s.maxLength)) {
+			return errs.err()
 		}
 	}
-	if s.pattern != nil && !s.pattern.Match(str) {
-		return errors.Errorf("does not match pattern %s", s.pattern)
+	if s.pattern != nil && !s.pattern.Match(str) &&
+		v.report(&errs, v.fail(s, "pattern", s.pattern.String(), string(str),
+			"%q does not match pattern %q", str, s.pattern)) {
+		return errs.err()
 	}
-	return nil
+	if s.contentCheck {
+		if err := s.validateContent(v, str); err != nil {
+			errs.add(err)
+		}
+	}
+	return errs.err()
 }
 
-func (s *Schema) validateNumber(d *jx.Decoder) error {
-	hasNumber := s.types.has(numberType)
+// validateContent validates string content against "contentEncoding" and
+// "contentMediaType".
+//
+// Unknown encodings and media types are ignored.
+func (s *Schema) validateContent(v *validator, str []byte) error {
+	content := str
+	switch s.contentEncoding {
+	case "base64":
+		decoded, err := base64.StdEncoding.DecodeString(string(str))
+		if err != nil {
+			return v.fail(s, "contentEncoding", s.contentEncoding, string(str),
+				"invalid %s: %s", s.contentEncoding, err)
+		}
+		content = decoded
+	}
 
-	if hasNumber && !(s.minimum != nil || s.maximum != nil || s.multipleOf != nil) {
+	mediaType, _, err := mime.ParseMediaType(s.contentMediaType)
+	if err != nil {
+		return nil
+	}
+	switch mediaType {
+	case "application/json":
+		if err := jx.DecodeBytes(content).Validate(); err != nil {
+			return v.fail(s, "contentMediaType", s.contentMediaType, string(str),
+				"invalid %s: %s", mediaType, err)
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateNumber(v *validator, d *jx.Decoder) error {
+	if s.types.has(numberType) && !s.hasNumberLimits() {
 		return d.Skip()
 	}
 
@@ -216,65 +878,154 @@ func (s *Schema) validateNumber(d *jx.Decoder) error {
 	if err != nil {
 		return errors.Wrap(err, "parse JSON")
 	}
+	return s.validateNum(v, num)
+}
+
+// hasNumberLimits reports whether schema has number keywords, except
+// "type".
+func (s *Schema) hasNumberLimits() bool {
+	return s.minimum != nil || s.maximum != nil ||
+		s.minimumExclusive != nil || s.maximumExclusive != nil ||
+		s.multipleOf != nil
+}
+
+// isIntegral reports whether number has zero fractional part, like 1.0
+// or 1e2.
+//
+// Number is checked lexically, to avoid parsing of numbers with huge
+// exponents.
+func isIntegral(num jx.Num) bool {
+	mant, exp := []byte(num), []byte(nil)
+	if i := bytes.IndexAny(mant, "eE"); i >= 0 {
+		mant, exp = mant[:i], mant[i+1:]
+	}
+	intPart, frac, _ := bytes.Cut(bytes.TrimPrefix(mant, []byte("-")), []byte("."))
 
-	if !hasNumber {
-		isInt := num.IsInt()
-		if isInt {
-			if err := s.checkType(integerType); err != nil {
-				return err
-			}
-		} else {
-			return s.checkType(numberType)
+	// Count trailing zeros of all mantissa digits.
+	zeros := len(frac) - len(bytes.TrimRight(frac, "0"))
+	if zeros == len(frac) {
+		zeros += len(intPart) - len(bytes.TrimRight(intPart, "0"))
+	}
+	if zeros == len(intPart)+len(frac) {
+		// Zero is integral with any exponent.
+		return true
+	}
+
+	var e int64
+	if len(exp) > 0 {
+		var err error
+		e, err = strconv.ParseInt(string(exp), 10, 64)
+		if err != nil {
+			// Exponent is out of range.
+			return exp[0] != '-'
 		}
 	}
+	// Number is digits * 10^(e - len(frac)), digits have trailing zeros.
+	return e >= int64(len(frac)-zeros)
+}
 
-	if s.minimum != nil || s.maximum != nil || s.multipleOf != nil {
+// validateNum validates number value against "type" and number keywords.
+func (s *Schema) validateNum(v *validator, num jx.Num) error {
+	var errs errorList
+	if !s.types.has(numberType) {
+		typ := numberType
+		if num.IsInt() || (s.integralFloats && isIntegral(num)) {
+			typ = integerType
+		}
+		if err := s.checkType(v, typ); err != nil && v.report(&errs, err) {
+			return errs.err()
+		}
+	}
+
+	if s.hasNumberLimits() {
 		val := new(big.Rat)
 		// TODO: more efficient way?
 		if err := val.UnmarshalText(num); err != nil {
 			return errors.Wrap(err, "parse")
 		}
-		if s.minimum != nil {
-			cmp := val.Cmp(s.minimum)
-			if (s.exclusiveMinimum && cmp <= 0) || cmp < 0 {
-				return errors.Errorf("value %s is smaller than %s", val, s.minimum)
+		actual := Num(copyRaw(num))
+		if m := s.minimum; m != nil {
+			cmp := val.Cmp(m.Rat)
+			switch {
+			case s.exclusiveMinimum && cmp <= 0:
+				if v.report(&errs, v.fail(s, "minimum", m.Raw, actual,
+					"value %s is smaller than or equal to %s", num, m.Raw)) {
+					return errs.err()
+				}
+			case cmp < 0:
+				if v.report(&errs, v.fail(s, "minimum", m.Raw, actual,
+					"value %s is smaller than %s", num, m.Raw)) {
+					return errs.err()
+				}
 			}
 		}
-		if s.maximum != nil {
-			cmp := val.Cmp(s.maximum)
-			if (s.exclusiveMaximum && cmp >= 0) || cmp > 0 {
-				return errors.Errorf("value %s is bigger than %s", val, s.maximum)
+		if m := s.maximum; m != nil {
+			cmp := val.Cmp(m.Rat)
+			switch {
+			case s.exclusiveMaximum && cmp >= 0:
+				if v.report(&errs, v.fail(s, "maximum", m.Raw, actual,
+					"value %s is bigger than or equal to %s", num, m.Raw)) {
+					return errs.err()
+				}
+			case cmp > 0:
+				if v.report(&errs, v.fail(s, "maximum", m.Raw, actual,
+					"value %s is bigger than %s", num, m.Raw)) {
+					return errs.err()
+				}
 			}
 		}
-		if s.multipleOf != nil {
-			if !val.Quo(val, s.multipleOf).IsInt() {
-				return errors.Errorf("%s is not multiple of %s", val, s.multipleOf)
+		if m := s.minimumExclusive; m != nil && val.Cmp(m.Rat) <= 0 &&
+			v.report(&errs, v.fail(s, "exclusiveMinimum", m.Raw, actual,
+				"value %s is smaller than or equal to %s", num, m.Raw)) {
+			return errs.err()
+		}
+		if m := s.maximumExclusive; m != nil && val.Cmp(m.Rat) >= 0 &&
+			v.report(&errs, v.fail(s, "exclusiveMaximum", m.Raw, actual,
+				"value %s is bigger than or equal to %s", num, m.Raw)) {
+			return errs.err()
+		}
+		if m := s.multipleOf; m != nil {
+			if !val.Quo(val, m.Rat).IsInt() {
+				errs.add(v.fail(s, "multipleOf", m.Raw, actual,
+					"%s is not multiple of %s", num, m.Raw))
 			}
 		}
 	}
 
-	return nil
+	return errs.err()
 }
 
-func (s *Schema) validateNull(d *jx.Decoder) error {
-	return s.skipType(d, nullType)
+func (s *Schema) validateNull(v *validator, d *jx.Decoder) error {
+	return s.skipType(v, d, nullType)
 }
 
-func (s *Schema) validateBool(d *jx.Decoder) error {
-	return s.skipType(d, booleanType)
+func (s *Schema) validateBool(v *validator, d *jx.Decoder) error {
+	return s.skipType(v, d, booleanType)
 }
 
-func (s *Schema) elemValidator(idx int) (*Schema, error) {
+// elemValidator returns schema for array element at given index.
+//
+// If schema is found, elemValidator pushes its location to the keyword location.
+func (s *Schema) elemValidator(v *validator, idx int) (*Schema, error) {
 	// 5.3.1.2.  Conditions for successful validation
 	//
 	// If "items" is not present, or its value is an object, validation
 	// of the instance always succeeds, regardless of the value of
 	// "additionalItems";
 	if obj := s.items.Object; !s.items.Set || obj != nil {
+		if obj != nil {
+			v.keyword.push("items")
+		}
 		return obj, nil
 	}
 
+	arrayKeyword, additionalKeyword := "items", "additionalItems"
+	if s.prefixItems {
+		arrayKeyword, additionalKeyword = "prefixItems", "items"
+	}
 	if arr := s.items.Array; idx < len(arr) {
+		v.keyword.push(arrayKeyword)
+		v.keyword.pushIndex(idx)
 		return arr[idx], nil
 	}
 
@@ -283,25 +1034,147 @@ func (s *Schema) elemValidator(idx int) (*Schema, error) {
 		return nil, nil
 	}
 	if ai.isSchema() {
+		v.keyword.push(additionalKeyword)
 		return ai.Schema, nil
 	}
 	if ai.Bool {
 		return nil, nil
 	}
-	return nil, errors.New("schema does not allow additionalItems")
+	return nil, v.fail(s, additionalKeyword, false, nil, "additional items are not allowed")
 }
 
-func (s *Schema) validateArray(d *jx.Decoder) error {
-	if err := s.checkType(arrayType); err != nil {
-		return err
+// evaluatesItem reports whether item at given index is evaluated by
+// "items" or "additionalItems".
+func (s *Schema) evaluatesItem(idx int) bool {
+	switch it := s.items; {
+	case !it.Set:
+		return false
+	case it.Object != nil || idx < len(it.Array):
+		return true
+	default:
+		return s.additionalItems.Set
 	}
+}
+
+// arrayState holds state of array validation.
+type arrayState struct {
+	// items is a list of array items, collected for "uniqueItems".
+	items []jx.Raw
+	// contains is a number of items, matching "contains".
+	contains int
+	// eval is a set of items, evaluated by adjacent keywords.
+	eval *evaluated
+}
 
-	if !(s.minItems.IsSet() ||
+// hasArrayChecks reports whether schema has array keywords, except "type".
+func (s *Schema) hasArrayChecks() bool {
+	return s.minItems.IsSet() ||
 		s.maxItems.IsSet() ||
 		s.uniqueItems ||
 		s.items.Set ||
-		s.additionalItems.Set) {
-		return d.Skip()
+		s.additionalItems.Set ||
+		s.contains != nil ||
+		s.unevaluatedItems != nil
+}
+
+// validateContains checks whether array item at given index matches
+// "contains".
+func (s *Schema) validateContains(v *validator, idx int, state *arrayState, apply applier) {
+	n := v.keyword.push("contains")
+	mark := v.trace.mark()
+	if v.valid(s.contains, apply) {
+		state.contains++
+		if s.containsEvaluates && state.eval != nil {
+			state.eval.addItem(idx)
+		}
+	}
+	// Only the number of matched items matters.
+	v.trace.discard(mark)
+	v.keyword.pop(n)
+}
+
+// itemSchema returns schema for array item at given index, including
+// "unevaluatedItems".
+//
+// If schema is found, itemSchema pushes its location to the keyword location.
+func (s *Schema) itemSchema(v *validator, idx int, state *arrayState) (*Schema, error) {
+	var errs errorList
+	sch, err := s.elemValidator(v, idx)
+	if err != nil && v.report(&errs, err) {
+		return nil, errs.err()
+	}
+	if u := s.unevaluatedItems; u != nil && sch == nil &&
+		!s.evaluatesItem(idx) && !state.eval.hasItem(idx) {
+		if u.never {
+			errs.add(v.fail(s, "unevaluatedItems", false, nil, "unevaluated items are not allowed"))
+			return nil, errs.err()
+		}
+		v.keyword.push("unevaluatedItems")
+		sch = u
+	}
+	return sch, errs.err()
+}
+
+func (s *Schema) validateItem(v *validator, d *jx.Decoder, idx int, state *arrayState) error {
+	var raw jx.Raw
+	if s.uniqueItems || s.contains != nil {
+		var err error
+		raw, err = d.Raw()
+		if err != nil {
+			return errors.Wrap(err, "parse JSON")
+		}
+	}
+	if s.uniqueItems {
+		item := raw
+		if v.stream {
+			item = jx.Raw(copyRaw(raw))
+		}
+		state.items = append(state.items, item)
+	}
+	if s.contains != nil {
+		s.validateContains(v, idx, state, func(sub *Schema) error {
+			return sub.validateRaw(v, raw)
+		})
+	}
+
+	n := v.keyword.len()
+	defer v.keyword.pop(n)
+
+	var errs errorList
+	sch, err := s.itemSchema(v, idx, state)
+	if err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+
+	switch {
+	case sch != nil && raw != nil:
+		if err := sch.validateRaw(v, raw); err != nil {
+			errs.add(err)
+		}
+	case raw != nil:
+	case sch != nil:
+		if err := sch.validate(v, d); err != nil {
+			errs.add(err)
+		}
+	default:
+		if err := d.Skip(); err != nil {
+			return errors.Wrap(err, "parse JSON")
+		}
+	}
+	return errs.err()
+}
+
+func (s *Schema) validateArray(v *validator, d *jx.Decoder) error {
+	var errs errorList
+	if err := s.checkType(v, arrayType); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+
+	if !s.hasArrayChecks() {
+		if err := d.Skip(); err != nil {
+			return err
+		}
+		return errs.err()
 	}
 
 	iter, err := d.ArrIter()
@@ -310,45 +1183,20 @@ func (s *Schema) validateArray(d *jx.Decoder) error {
 	}
 	var (
 		i     = 0
-		items []jx.Raw
+		state = arrayState{eval: v.eval}
 	)
+	// Items are different instances.
+	v.eval = nil
+	defer func() {
+		v.eval = state.eval
+	}()
 	for iter.Next() {
-		sch, err := s.elemValidator(i)
-		if err != nil {
-			return err
-		}
-		if sch != nil || s.uniqueItems {
-			if err := func() error {
-				switch {
-				case sch != nil && s.uniqueItems:
-					raw, err := d.Raw()
-					if err != nil {
-						return errors.Wrap(err, "parse JSON")
-					}
-					items = append(items, raw)
+		n := v.instance.pushIndex(i)
+		err := s.validateItem(v, d, i, &state)
+		v.instance.pop(n)
 
-					if err := sch.Validate(raw); err != nil {
-						return err
-					}
-				case s.uniqueItems:
-					raw, err := d.Raw()
-					if err != nil {
-						return errors.Wrap(err, "parse JSON")
-					}
-					items = append(items, raw)
-				case sch != nil:
-					if err := sch.validate(d); err != nil {
-						return err
-					}
-				}
-				return nil
-			}(); err != nil {
-				return errors.Wrapf(err, "[%d]", i)
-			}
-		} else {
-			if err := d.Skip(); err != nil {
-				return errors.Wrap(err, "parse JSON")
-			}
+		if err != nil && v.report(&errs, err) {
+			return errs.err()
 		}
 		i++
 	}
@@ -356,148 +1204,378 @@ func (s *Schema) validateArray(d *jx.Decoder) error {
 		return errors.Wrap(err, "parse JSON")
 	}
 
-	if len(items) > 1 {
+	if err := s.validateArrayEnd(v, i, &state); err != nil {
+		errs.add(err)
+	}
+	return errs.err()
+}
+
+// validateArrayEnd validates array keywords, that depend on all items.
+//
+// count is a number of items.
+func (s *Schema) validateArrayEnd(v *validator, count int, state *arrayState) error {
+	if ev := state.eval; ev != nil {
+		switch it := s.items; {
+		case s.unevaluatedItems != nil || it.Object != nil || (it.Set && s.additionalItems.Set):
+			ev.allItems = true
+		case it.Set:
+			ev.items = max(ev.items, len(it.Array))
+		}
+	}
+
+	var errs errorList
+	if s.contains != nil {
+		switch matched := state.contains; {
+		case s.minContains.IsSet():
+			if matched < int(s.minContains) &&
+				v.report(&errs, v.fail(s, "minContains", int(s.minContains), matched,
+					"%d items match contains, less than %d", matched, s.minContains)) {
+				return errs.err()
+			}
+		case matched == 0:
+			if v.report(&errs, v.fail(s, "contains", nil, nil, "no items match contains")) {
+				return errs.err()
+			}
+		}
+		if s.maxContains.IsSet() && state.contains > int(s.maxContains) &&
+			v.report(&errs, v.fail(s, "maxContains", int(s.maxContains), state.contains,
+				"%d items match contains, more than %d", state.contains, s.maxContains)) {
+			return errs.err()
+		}
+	}
+
+	if items := state.items; len(items) > 1 {
+	uniqueLoop:
 		for xi, x := range items {
 			for yi, y := range items {
 				if xi == yi {
 					continue
 				}
 				if ok, _ := jsonequal.Equal(x, y); ok {
-					return errors.Errorf("items %d and %d are equal", xi, yi)
+					if v.report(&errs, v.fail(s, "uniqueItems", true, []int{xi, yi},
+						"items %d and %d are equal", xi, yi)) {
+						return errs.err()
+					}
+					break uniqueLoop
 				}
 			}
 		}
 	}
 
-	if s.minItems.IsSet() && i < int(s.minItems) {
-		return errors.Errorf("length is smaller than %d", s.minItems)
+	if s.minItems.IsSet() && count < int(s.minItems) &&
+		v.report(&errs, v.fail(s, "minItems", int(s.minItems), count,
+			"length %d is smaller than %d", count, s.minItems)) {
+		return errs.err()
 	}
-	if s.maxItems.IsSet() && i > int(s.maxItems) {
-		return errors.Errorf("length is bigger than %d", s.maxItems)
+	if s.maxItems.IsSet() && count > int(s.maxItems) {
+		errs.add(v.fail(s, "maxItems", int(s.maxItems), count,
+			"length %d is bigger than %d", count, s.maxItems))
 	}
 
-	return nil
+	return errs.err()
 }
 
-func (s *Schema) validateObject(d *jx.Decoder) error {
-	if err := s.checkType(objectType); err != nil {
-		return err
+// validateProperty validates object property against all matching validators.
+//
+// apply validates the property value against subschema.
+func (s *Schema) validateProperty(v *validator, key []byte, apply applier) error {
+	var (
+		matched bool
+		errs    errorList
+	)
+	for _, p := range s.patternProperties {
+		if p.Regexp.Match(key) {
+			matched = true
+
+			n := v.keyword.push("patternProperties")
+			v.keyword.push(p.Regexp.String())
+			err := apply(p.Schema)
+			v.keyword.pop(n)
+
+			if err != nil && v.report(&errs, err) {
+				return errs.err()
+			}
+		}
+	}
+	if prop, ok := s.properties[string(key)]; ok {
+		n := v.keyword.push("properties")
+		v.keyword.pushBytes(key)
+		err := apply(prop)
+		v.keyword.pop(n)
+
+		if err != nil {
+			errs.add(err)
+		}
+		return errs.err()
+	}
+
+	if matched {
+		return errs.err()
+	}
+
+	ap := s.additionalProperties
+	if ap.Set && ap.Schema == nil && !ap.Bool {
+		errs.add(v.fail(s, "additionalProperties", false, string(key),
+			"additional property %q is not allowed", key))
+		return errs.err()
+	}
+	if sch := ap.Schema; sch != nil {
+		n := v.keyword.push("additionalProperties")
+		err := apply(sch)
+		v.keyword.pop(n)
+
+		if err != nil {
+			errs.add(err)
+		}
+	}
+
+	return errs.err()
+}
+
+// evaluatesProperty reports whether property is evaluated by "properties",
+// "patternProperties" or "additionalProperties".
+func (s *Schema) evaluatesProperty(key []byte) bool {
+	if _, ok := s.properties[string(key)]; ok || s.additionalProperties.Set {
+		return true
+	}
+	for _, p := range s.patternProperties {
+		if p.Regexp.Match(key) {
+			return true
+		}
 	}
+	return false
+}
 
-	if !(s.minProperties.IsSet() ||
+// markProperty adds property to the set of evaluated properties and
+// reports whether it should be validated against "unevaluatedProperties".
+func (s *Schema) markProperty(eval *evaluated, key []byte) (unevaluated bool) {
+	if eval == nil {
+		return false
+	}
+	evaluated := s.evaluatesProperty(key)
+	if !evaluated && s.unevaluatedProperties != nil {
+		unevaluated = !eval.hasProp(key)
+		evaluated = true
+	}
+	if evaluated {
+		eval.addProp(key)
+	}
+	return unevaluated
+}
+
+// validateUnevaluatedProperty validates object property against
+// "unevaluatedProperties".
+func (s *Schema) validateUnevaluatedProperty(v *validator, key []byte, apply applier) error {
+	u := s.unevaluatedProperties
+	if u.never {
+		return v.fail(s, "unevaluatedProperties", false, string(key),
+			"unevaluated property %q is not allowed", key)
+	}
+
+	n := v.keyword.push("unevaluatedProperties")
+	defer v.keyword.pop(n)
+	return apply(u)
+}
+
+// validatePropertyName validates object key against "propertyNames".
+func (s *Schema) validatePropertyName(v *validator, key []byte) error {
+	if s.propertyNames == nil {
+		return nil
+	}
+
+	e := jx.GetEncoder()
+	defer jx.PutEncoder(e)
+	e.ByteStr(key)
+
+	n := v.instance.pushBytes(key)
+	k := v.keyword.push("propertyNames")
+	err := s.propertyNames.validateRaw(v, e.Bytes())
+	v.keyword.pop(k)
+	v.instance.pop(n)
+	return err
+}
+
+// hasObjectChecks reports whether schema has object keywords, except
+// "type".
+func (s *Schema) hasObjectChecks() bool {
+	return s.minProperties.IsSet() ||
 		s.maxProperties.IsSet() ||
 		len(s.required) > 0 ||
 		len(s.properties) > 0 ||
 		len(s.patternProperties) > 0 ||
 		s.additionalProperties.Set ||
-		len(s.dependentSchemas) > 0 ||
-		len(s.dependentRequired) > 0) {
-		return d.Skip()
+		s.hasDependent() ||
+		s.propertyNames != nil ||
+		s.unevaluatedProperties != nil
+}
+
+// hasDependent reports whether schema has keywords, that depend on
+// present properties.
+func (s *Schema) hasDependent() bool {
+	return len(s.dependentSchemas) > 0 || len(s.dependentRequired) > 0
+}
+
+// dependentNames returns names of "dependentSchemas" and
+// "dependentRequired" keywords, used by schema draft.
+func (s *Schema) dependentNames() (schemas, required string) {
+	if s.dependentKeywords {
+		return "dependentSchemas", "dependentRequired"
+	}
+	return "dependencies", "dependencies"
+}
+
+type dependentSchema struct {
+	name   string
+	schema *Schema
+}
+
+// addDependent collects dependencies of present property to the set of
+// required properties and the list of dependent schemas.
+func (s *Schema) addDependent(required map[string]string, dependent []dependentSchema, key []byte) []dependentSchema {
+	if r, ok := s.dependentRequired[string(key)]; ok {
+		for _, value := range r {
+			if _, ok := required[value]; !ok {
+				required[value] = string(key)
+			}
+		}
+	}
+	if sch, ok := s.dependentSchemas[string(key)]; ok {
+		dependent = append(dependent, dependentSchema{
+			name:   string(key),
+			schema: sch,
+		})
 	}
+	return dependent
+}
+
+// validateDependentSchemas validates object against collected dependent
+// schemas.
+//
+// apply validates the object against subschema.
+func (s *Schema) validateDependentSchemas(v *validator, dependent []dependentSchema, apply applier) error {
+	var errs errorList
+	schemasKeyword, _ := s.dependentNames()
+	for _, ds := range dependent {
+		n := v.keyword.push(schemasKeyword)
+		v.keyword.push(ds.name)
+		err := apply(ds.schema)
+		v.keyword.pop(n)
 
-	type dependentSchema struct {
-		name   string
-		schema *Schema
+		if err != nil && v.report(&errs, err) {
+			return errs.err()
+		}
 	}
+	return errs.err()
+}
+
+func (s *Schema) validateObject(v *validator, d *jx.Decoder) error {
+	var errs errorList
+	if err := s.checkType(v, objectType); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+
+	if !s.hasObjectChecks() {
+		if err := d.Skip(); err != nil {
+			return err
+		}
+		return errs.err()
+	}
+
 	var (
-		i        = 0
-		required map[string]struct{}
+		// required maps required property name to the name of property
+		// that requires it, or to an empty string, if it is required by
+		// "required" keyword.
+		required map[string]string
 		// Stack-allocated slice.
 		dependent = make([]dependentSchema, 0, 8)
 	)
-
-	if len(s.required) > 0 {
-		required = make(map[string]struct{}, len(s.required))
+	if len(s.required) > 0 || len(s.dependentRequired) > 0 {
+		required = make(map[string]string, len(s.required))
 		for k := range s.required {
-			required[k] = struct{}{}
+			required[k] = ""
 		}
 	}
-	if len(s.dependentRequired) > 0 || len(s.dependentSchemas) > 0 {
-		if len(s.dependentRequired) > 0 && required == nil {
-			required = map[string]struct{}{}
-		}
+	if s.hasDependent() {
+		if v.stream {
+			// Dependencies are collected by the separate pass, buffer
+			// the object to rewind the decoder.
+			data, err := d.Raw()
+			if err != nil {
+				return errors.Wrap(err, "parse JSON")
+			}
 
+			d = jx.GetDecoder()
+			defer jx.PutDecoder(d)
+			d.ResetBytes(data)
+		}
 		if err := d.Capture(func(d *jx.Decoder) error {
 			return d.ObjBytes(func(d *jx.Decoder, key []byte) error {
-				if r, ok := s.dependentRequired[string(key)]; ok {
-					for _, value := range r {
-						required[value] = struct{}{}
-					}
-				}
-				if s, ok := s.dependentSchemas[string(key)]; ok {
-					dependent = append(dependent, dependentSchema{
-						name:   string(key),
-						schema: s,
-					})
-				}
+				dependent = s.addDependent(required, dependent, key)
 				return d.Skip()
 			})
 		}); err != nil {
 			return errors.Wrap(err, "collect dependent")
 		}
 	}
-	if len(dependent) > 0 {
-		for _, ds := range dependent {
-			if err := d.Capture(func(d *jx.Decoder) error {
-				return ds.schema.validate(d)
-			}); err != nil {
-				return errors.Wrapf(err, "dependent %q", ds.name)
-			}
-		}
+	if err := s.validateDependentSchemas(v, dependent, func(sub *Schema) error {
+		return d.Capture(func(d *jx.Decoder) error {
+			return sub.validate(v, d)
+		})
+	}); err != nil && v.report(&errs, err) {
+		return errs.err()
 	}
 
 	multiPass := s.additionalProperties.Set ||
 		len(s.patternProperties) > 0
 
+	eval := v.eval
+	// Property values are different instances.
+	v.eval = nil
+	defer func() {
+		v.eval = eval
+	}()
+
 	iter, err := d.ObjIter()
 	if err != nil {
 		return errors.Wrap(err, "parse JSON")
 	}
+	i := 0
 	for iter.Next() {
 		k := iter.Key()
 		delete(required, string(k))
 
-		if prop, ok := s.properties[string(k)]; ok || multiPass {
-			if err := func() error {
-				if !multiPass {
-					return prop.validate(d)
+		if err := s.validatePropertyName(v, k); err != nil && v.report(&errs, err) {
+			return errs.err()
+		}
+
+		unevaluated := s.markProperty(eval, k)
+		if prop, ok := s.properties[string(k)]; ok || multiPass || unevaluated {
+			n := v.instance.pushBytes(k)
+			err := func() error {
+				if !multiPass && !unevaluated {
+					n := v.keyword.push("properties")
+					v.keyword.pushBytes(k)
+					defer v.keyword.pop(n)
+
+					return prop.validate(v, d)
 				}
 
 				item, err := d.Raw()
 				if err != nil {
 					return errors.Wrap(err, "parse JSON")
 				}
-
-				var matched bool
-				for _, p := range s.patternProperties {
-					if p.Regexp.Match(k) {
-						matched = true
-						if err := p.Schema.Validate(item); err != nil {
-							return errors.Wrapf(err, "pattern %q", p.Regexp)
-						}
-					}
-				}
-				if ok {
-					return prop.Validate(item)
-				}
-
-				if matched {
-					return nil
-				}
-
-				ap := s.additionalProperties
-				if ap.Set && ap.Schema == nil && !ap.Bool {
-					return errors.New("additional properties are not allowed")
+				apply := func(sub *Schema) error {
+					return sub.validateRaw(v, item)
 				}
-				if sch := ap.Schema; sch != nil {
-					if err := sch.Validate(item); err != nil {
-						return errors.Wrap(err, "additionalProperties")
-					}
+				if unevaluated {
+					return s.validateUnevaluatedProperty(v, k, apply)
 				}
+				return s.validateProperty(v, k, apply)
+			}()
+			v.instance.pop(n)
 
-				return nil
-			}(); err != nil {
-				return errors.Wrapf(err, "%q", k)
+			if err != nil && v.report(&errs, err) {
+				return errs.err()
 			}
 		} else {
 			if err := d.Skip(); err != nil {
@@ -510,16 +1588,58 @@ func (s *Schema) validateObject(d *jx.Decoder) error {
 		return errors.Wrap(err, "parse JSON")
 	}
 
-	for k := range required {
-		return errors.Errorf("required property %q is missing", k)
+	if err := s.validateObjectEnd(v, i, required); err != nil {
+		errs.add(err)
 	}
+	return errs.err()
+}
 
-	if s.minProperties.IsSet() && i < int(s.minProperties) {
-		return errors.Errorf("length is smaller than %d", s.minProperties)
+// validateObjectEnd validates object keywords, that depend on all
+// properties.
+//
+// count is a number of properties, required is a set of missing required
+// properties.
+func (s *Schema) validateObjectEnd(v *validator, count int, required map[string]string) error {
+	var errs errorList
+	if len(required) > 0 {
+		missing := make([]string, 0, len(required))
+		for k := range required {
+			missing = append(missing, k)
+		}
+		// Report missing properties in stable order.
+		sort.Strings(missing)
+
+		_, requiredKeyword := s.dependentNames()
+		for _, k := range missing {
+			var e *ValidationError
+			if by := required[k]; by != "" {
+				e = v.fail(s, requiredKeyword, k, nil, "property %q is required by %q", k, by)
+				suffix := "/" + escape(by)
+				e.KeywordLocation += suffix
+				e.AbsoluteKeywordLocation += suffix
+			} else {
+				e = v.fail(s, "required", k, nil, "required property %q is missing", k)
+			}
+			if v.report(&errs, e) {
+				return errs.err()
+			}
+		}
+	}
+
+	if s.minProperties.IsSet() && count < int(s.minProperties) &&
+		v.report(&errs, v.fail(s, "minProperties", int(s.minProperties), count,
+			"length %d is smaller than %d", count, s.minProperties)) {
+		return errs.err()
 	}
-	if s.maxProperties.IsSet() && i > int(s.maxProperties) {
-		return errors.Errorf("length is bigger than %d", s.maxProperties)
+	if s.maxProperties.IsSet() && count > int(s.maxProperties) {
+		errs.add(v.fail(s, "maxProperties", int(s.maxProperties), count,
+			"length %d is bigger than %d", count, s.maxProperties))
 	}
 
-	return nil
+	return errs.err()
+}
+
+// copyRaw returns a copy of given JSON value.
+func copyRaw(data []byte) json.RawMessage {
+	return append(json.RawMessage(nil), data...)
 }
@@ -1,10 +1,17 @@
 package jsonschema
 
 import (
+	"bufio"
+	"bytes"
 	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
 	"path"
+	"runtime"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/require"
 )
@@ -74,6 +81,24 @@ func TestBenchSuite(t *testing.T) {
 	}
 }
 
+func BenchmarkParse(b *testing.B) {
+	const root = "_bench"
+	for _, e := range mustDir(b, bench, root) {
+		schema := mustFile(b, bench, path.Join(root, e.Name(), "schema.json"))
+		b.Run(e.Name(), func(b *testing.B) {
+			b.SetBytes(int64(len(schema)))
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := Parse(schema); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkValidate(b *testing.B) {
 	for _, s := range collectBench(b) {
 		s := s
@@ -99,3 +124,454 @@ func BenchmarkValidate(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkValidateLocations compares validation of valid data without
+// locations to validation that tracks them.
+func BenchmarkValidateLocations(b *testing.B) {
+	for _, s := range collectBench(b) {
+		s := s
+		if s.Skip {
+			continue
+		}
+		for _, data := range s.Data {
+			data := data
+			for _, m := range []struct {
+				name     string
+				validate func([]byte) error
+			}{
+				{"Validate", s.Schema.Validate},
+				{"ValidateAll", s.Schema.ValidateAll},
+			} {
+				b.Run(path.Join(s.Name, data.Name, m.name), func(b *testing.B) {
+					b.SetBytes(int64(len(data.Data)))
+					b.ReportAllocs()
+					b.ResetTimer()
+
+					for i := 0; i < b.N; i++ {
+						if err := m.validate(data.Data); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestSchema_ValidateAll(t *testing.T) {
+	sch, err := Parse([]byte(`{
+  "type": "object",
+  "required": ["id", "name"],
+  "properties": {
+    "number": { "type": "number", "minimum": 10 },
+    "tags": {
+      "type": "array",
+      "items": { "type": "string", "maxLength": 3 },
+      "uniqueItems": true
+    },
+    "kind": { "enum": ["a", "b"], "type": "integer" }
+  }
+}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		data string
+		errs []string
+	}{
+		{`{"id": 1, "name": "foo"}`, nil},
+		{`{"id": 1, "name": "foo", "number": 1}`, []string{
+			`#/number: minimum: value 1 is smaller than 10`,
+		}},
+		{`{"number": "1", "tags": ["a", "abcd", 1, "a"], "kind": "c"}`, []string{
+			`#/number: type: string is not allowed`,
+			`#/tags/1: maxLength: length 4 is bigger than 3`,
+			`#/tags/2: type: integer is not allowed`,
+			`#/tags: uniqueItems: items 0 and 3 are equal`,
+			`#/kind: enum: value "c" is not present in enum`,
+			`#/kind: type: string is not allowed`,
+			`#: required: required property "id" is missing`,
+			`#: required: required property "name" is missing`,
+		}},
+	}
+	for i, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprintf("Test%d", i+1), func(t *testing.T) {
+			a := require.New(t)
+
+			err := sch.ValidateAll([]byte(tt.data))
+			if len(tt.errs) == 0 {
+				a.NoError(err)
+				return
+			}
+
+			var errs ValidationErrors
+			a.ErrorAs(err, &errs)
+			msgs := make([]string, len(errs))
+			for i, err := range errs {
+				msgs[i] = err.Error()
+			}
+			a.Equal(tt.errs, msgs)
+
+			// Validate must return the first error.
+			a.EqualError(sch.Validate([]byte(tt.data)), tt.errs[0])
+		})
+	}
+
+	// Invalid JSON.
+	require.Error(t, sch.ValidateAll([]byte(`{"number": }`)))
+}
+
+func TestValidationError(t *testing.T) {
+	sch, err := Parse([]byte(`{
+  "id": "http://example.com/root.json",
+  "definitions": {
+    "port": { "type": "integer", "maximum": 65535 }
+  },
+  "type": "object",
+  "properties": {
+    "ports": {
+      "type": "array",
+      "items": { "$ref": "#/definitions/port" }
+    },
+    "mode": {
+      "anyOf": [
+        { "type": "string", "enum": ["tcp", "udp"] },
+        { "type": "null" }
+      ]
+    }
+  },
+  "additionalProperties": false
+}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		data string
+		want []*ValidationError
+	}{
+		{`{"ports": [80, 65536]}`, []*ValidationError{
+			{
+				InstanceLocation:        "/ports/1",
+				KeywordLocation:         "/properties/ports/items/$ref/maximum",
+				AbsoluteKeywordLocation: "http://example.com/root.json#/definitions/port/maximum",
+				Keyword:                 "maximum",
+				Expected:                Num("65535"),
+				Actual:                  Num("65536"),
+				Message:                 "value 65536 is bigger than 65535",
+			},
+		}},
+		{`{"foo": 1}`, []*ValidationError{
+			{
+				InstanceLocation:        "/foo",
+				KeywordLocation:         "/additionalProperties",
+				AbsoluteKeywordLocation: "http://example.com/root.json#/additionalProperties",
+				Keyword:                 "additionalProperties",
+				Expected:                false,
+				Actual:                  "foo",
+				Message:                 `additional property "foo" is not allowed`,
+			},
+		}},
+		{`{"mode": "sctp"}`, []*ValidationError{
+			{
+				InstanceLocation:        "/mode",
+				KeywordLocation:         "/properties/mode/anyOf",
+				AbsoluteKeywordLocation: "http://example.com/root.json#/properties/mode/anyOf",
+				Keyword:                 "anyOf",
+				Message:                 "must match at least once",
+				Causes: []*ValidationError{
+					{
+						InstanceLocation:        "/mode",
+						KeywordLocation:         "/properties/mode/anyOf/0/enum",
+						AbsoluteKeywordLocation: "http://example.com/root.json#/properties/mode/anyOf/0/enum",
+						Keyword:                 "enum",
+						Expected:                []json.RawMessage{[]byte(`"tcp"`), []byte(`"udp"`)},
+						Actual:                  json.RawMessage(`"sctp"`),
+						Message:                 `value "sctp" is not present in enum`,
+					},
+					{
+						InstanceLocation:        "/mode",
+						KeywordLocation:         "/properties/mode/anyOf/1/type",
+						AbsoluteKeywordLocation: "http://example.com/root.json#/properties/mode/anyOf/1/type",
+						Keyword:                 "type",
+						Expected:                []string{"null"},
+						Actual:                  "string",
+						Message:                 "string is not allowed",
+					},
+				},
+			},
+		}},
+	}
+	for i, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprintf("Test%d", i+1), func(t *testing.T) {
+			a := require.New(t)
+
+			err := sch.ValidateAll([]byte(tt.data))
+			var errs ValidationErrors
+			a.ErrorAs(err, &errs)
+			a.Equal(ValidationErrors(tt.want), errs)
+		})
+	}
+}
+
+func TestSchema_ValidateContent(t *testing.T) {
+	schema := []byte(`{
+	"properties": {
+		"encoded": {"contentEncoding": "base64", "contentMediaType": "application/json"},
+		"raw": {"contentMediaType": "application/json; charset=utf-8"},
+		"unknown": {"contentEncoding": "base32", "contentMediaType": "image/png"}
+	}
+}`)
+	tests := []struct {
+		data    string
+		keyword string
+	}{
+		{`{"encoded": "eyJmb28iOiAiYmFyIn0K"}`, ""},
+		{`{"encoded": "eyJmb28iOi%iYmFyIn0K"}`, "contentEncoding"},
+		{`{"encoded": "e30="}`, ""},
+		// "{:}" in base64.
+		{`{"encoded": "ezp9"}`, "contentMediaType"},
+		{`{"raw": "{\"foo\": 1}"}`, ""},
+		{`{"raw": "{:}"}`, "contentMediaType"},
+		{`{"unknown": "foo"}`, ""},
+	}
+	for i, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprintf("Test%d", i+1), func(t *testing.T) {
+			a := require.New(t)
+
+			// Content keywords are annotations by default.
+			annotation, err := ParseWithOptions(schema, Options{Draft: Draft7})
+			a.NoError(err)
+			a.NoError(annotation.Validate([]byte(tt.data)))
+
+			assertion, err := ParseWithOptions(schema, Options{Draft: Draft7, AssertContent: true})
+			a.NoError(err)
+			err = assertion.Validate([]byte(tt.data))
+			if tt.keyword == "" {
+				a.NoError(err)
+				return
+			}
+			var e *ValidationError
+			a.ErrorAs(err, &e)
+			a.Equal(tt.keyword, e.Keyword)
+		})
+	}
+}
+
+func TestSchema_ValidateInteger(t *testing.T) {
+	tests := []struct {
+		data   string
+		draft4 bool
+		draft6 bool
+	}{
+		{`1`, true, true},
+		{`-1`, true, true},
+		{`1.0`, false, true},
+		{`-10.000`, false, true},
+		{`0.0e-10`, false, true},
+		{`1e2`, false, true},
+		{`1.5e1`, false, true},
+		{`150e-1`, false, true},
+		{`1.25e1`, false, false},
+		{`15e-1`, false, false},
+		{`1.5`, false, false},
+		{`1e99999999999999999999`, false, true},
+		{`1e-99999999999999999999`, false, false},
+	}
+	for i, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprintf("Test%d", i+1), func(t *testing.T) {
+			a := require.New(t)
+
+			for _, c := range []struct {
+				draft Draft
+				valid bool
+			}{
+				{Draft4, tt.draft4},
+				{Draft6, tt.draft6},
+			} {
+				s, err := ParseWithOptions([]byte(`{"type": "integer"}`), Options{Draft: c.draft})
+				a.NoError(err)
+				if err := s.Validate([]byte(tt.data)); c.valid {
+					a.NoError(err, "draft %v", c.draft)
+				} else {
+					a.Error(err, "draft %v", c.draft)
+				}
+			}
+		})
+	}
+}
+
+func TestSchema_ValidateDraft201909(t *testing.T) {
+	sch, err := ParseWithOptions([]byte(`{
+	"$defs": {
+		"port": {"type": "integer", "maximum": 65535}
+	},
+	"properties": {
+		"port": {"$ref": "#/$defs/port", "minimum": 1024},
+		"tags": {"contains": {"const": "prod"}, "maxContains": 1},
+		"tls": {"type": "boolean"}
+	},
+	"dependentRequired": {"tls": ["port"]},
+	"unevaluatedProperties": false
+}`), Options{Draft: Draft201909})
+	require.NoError(t, err)
+
+	tests := []struct {
+		data            string
+		keywordLocation string
+		keyword         string
+	}{
+		{`{"port": 8080, "tags": ["prod"]}`, "", ""},
+		{`{"port": 65536}`, "/properties/port/$ref/maximum", "maximum"},
+		{`{"port": 80}`, "/properties/port/minimum", "minimum"},
+		{`{"tags": ["prod", "prod"]}`, "/properties/tags/maxContains", "maxContains"},
+		{`{"tls": true}`, "/dependentRequired/tls", "dependentRequired"},
+		{`{"port": 8080, "host": "localhost"}`, "/unevaluatedProperties", "unevaluatedProperties"},
+	}
+	for i, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprintf("Test%d", i+1), func(t *testing.T) {
+			a := require.New(t)
+
+			err := sch.Validate([]byte(tt.data))
+			if tt.keyword == "" {
+				a.NoError(err)
+				return
+			}
+			var e *ValidationError
+			a.ErrorAs(err, &e)
+			a.Equal(tt.keywordLocation, e.KeywordLocation)
+			a.Equal(tt.keyword, e.Keyword)
+		})
+	}
+}
+
+func TestSchema_ValidateDraft202012(t *testing.T) {
+	sch, err := ParseWithOptions([]byte(`{
+	"prefixItems": [{"type": "string"}],
+	"items": false
+}`), Options{Draft: Draft202012})
+	require.NoError(t, err)
+
+	tests := []struct {
+		data            string
+		keywordLocation string
+		keyword         string
+	}{
+		{`["foo"]`, "", ""},
+		{`[1]`, "/prefixItems/0/type", "type"},
+		{`["foo", "bar"]`, "/items", "items"},
+	}
+	for i, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprintf("Test%d", i+1), func(t *testing.T) {
+			a := require.New(t)
+
+			err := sch.Validate([]byte(tt.data))
+			if tt.keyword == "" {
+				a.NoError(err)
+				return
+			}
+			var e *ValidationError
+			a.ErrorAs(err, &e)
+			a.Equal(tt.keywordLocation, e.KeywordLocation)
+			a.Equal(tt.keyword, e.Keyword)
+		})
+	}
+
+	// Array form of "items" is replaced by "prefixItems".
+	_, err = ParseWithOptions([]byte(`{"items": [{}]}`), Options{Draft: Draft202012})
+	require.Error(t, err)
+}
+
+func TestSchema_ValidateReader(t *testing.T) {
+	t.Run("Bench", func(t *testing.T) {
+		for _, s := range collectBench(t) {
+			s := s
+			t.Run(s.Name, func(t *testing.T) {
+				for _, data := range s.Data {
+					data := data
+					t.Run(data.Name, func(t *testing.T) {
+						a := require.New(t)
+						a.NoError(s.Schema.ValidateReader(bytes.NewReader(data.Data)))
+						a.NoError(s.Schema.ValidateReader(iotest.HalfReader(bytes.NewReader(data.Data))))
+					})
+				}
+			})
+		}
+	})
+	t.Run("Error", func(t *testing.T) {
+		a := require.New(t)
+
+		sch, err := Parse([]byte(`{"items": {"type": "integer"}, "uniqueItems": true}`))
+		a.NoError(err)
+
+		a.EqualError(
+			sch.ValidateReader(strings.NewReader(`[1, 2, "3"]`)),
+			`#/2: type: string is not allowed`,
+		)
+		a.EqualError(
+			sch.ValidateReader(iotest.OneByteReader(strings.NewReader(`[1, 2, 1]`))),
+			`#: uniqueItems: items 0 and 2 are equal`,
+		)
+		a.Error(sch.ValidateReader(strings.NewReader(`[1, 2`)))
+		a.ErrorIs(sch.ValidateReader(iotest.ErrReader(io.ErrClosedPipe)), io.ErrClosedPipe)
+	})
+	// largeArray generates about 25 MiB of items.
+	largeArray := func() io.Reader {
+		const count = 1 << 20
+		item := []byte(`[1234567890, 1234567890],`)
+		pr, pw := io.Pipe()
+		go func() {
+			w := bufio.NewWriter(pw)
+			_ = w.WriteByte('[')
+			for i := 0; i < count; i++ {
+				_, _ = w.Write(item)
+			}
+			_, _ = w.WriteString(`[]]`)
+			_ = w.Flush()
+			_ = pw.Close()
+		}()
+		return pr
+	}
+	for _, tt := range []struct {
+		name   string
+		schema string
+	}{
+		{"Large", `{
+	"type": "array",
+	"items": {"type": "array", "items": {"type": "number"}}
+}`},
+		// Since Draft 2019-09, "$ref" is an applicator, but the value is
+		// not buffered, if there are no other keywords to apply.
+		{"LargeRef", `{
+	"$schema": "https://json-schema.org/draft/2019-09/schema",
+	"$ref": "#/$defs/list",
+	"type": "array",
+	"$defs": {
+		"list": {"items": {"$ref": "#/$defs/pair"}},
+		"pair": {"$ref": "#/$defs/numbers"},
+		"numbers": {"type": "array", "items": {"type": "number"}}
+	}
+}`},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			a := require.New(t)
+
+			sch, err := Parse([]byte(tt.schema))
+			a.NoError(err)
+
+			r := largeArray()
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			a.NoError(sch.ValidateReader(r))
+			runtime.ReadMemStats(&after)
+
+			// Data must not be buffered.
+			allocated := after.TotalAlloc - before.TotalAlloc
+			a.Less(allocated, uint64(1<<20))
+		})
+	}
+}
@@ -0,0 +1,851 @@
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/go-faster/errors"
+	"github.com/go-faster/jx"
+)
+
+// ValidateValue validates given Go value as if it is encoded by
+// encoding/json.
+//
+// Maps, slices, structs and scalar values are walked directly, without
+// encoding the whole value. Struct fields are handled like encoding/json
+// does, honoring "json" tags. Values, implementing json.Marshaler or
+// encoding.TextMarshaler, are encoded using these interfaces. Keywords,
+// that compare whole values, like "enum", "const" and "uniqueItems",
+// encode compared values. Parts of value, not checked by the schema, are
+// not walked, so unsupported values in them are not reported.
+//
+// Validation stops at the first error. If value can be encoded, returned
+// error is *ValidationError. Like encoding/json, ValidateValue returns
+// *json.UnsupportedValueError, if value contains a cycle.
+func (s *Schema) ValidateValue(val any) error {
+	gv, err := newGoValue(reflect.ValueOf(val), false)
+	if err != nil {
+		return err
+	}
+	v := getValidator()
+	defer putValidator(v)
+	return v.locate(func(v *validator) error {
+		return s.validateValue(v, gv)
+	})
+}
+
+// goValue is a Go value, prepared for validation.
+type goValue struct {
+	// rv is a value without pointers and interfaces.
+	//
+	// Zero rv is JSON null.
+	rv reflect.Value
+	// raw is a JSON value, if value is encoded by json.Marshaler,
+	// encoding.TextMarshaler or ",string" option.
+	raw []byte
+}
+
+var (
+	marshalerType     = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	jsonNumberType    = reflect.TypeFor[json.Number]()
+)
+
+// newGoValue dereferences given value and encodes it, if it implements
+// json.Marshaler or encoding.TextMarshaler.
+//
+// If quoted is set, scalar value is encoded as JSON string.
+func newGoValue(rv reflect.Value, quoted bool) (goValue, error) {
+	for {
+		if !rv.IsValid() {
+			return goValue{}, nil
+		}
+		if m, ok := marshalerOf(rv); ok {
+			raw, err := json.Marshal(m)
+			if err != nil {
+				return goValue{}, err
+			}
+			return goValue{raw: raw}, nil
+		}
+		if k := rv.Kind(); k != reflect.Pointer && k != reflect.Interface {
+			break
+		}
+		if rv.IsNil() {
+			return goValue{}, nil
+		}
+		rv = rv.Elem()
+	}
+
+	if quoted && (isScalarKind(rv.Kind()) || rv.Kind() == reflect.String) {
+		raw, err := json.Marshal(rv.Interface())
+		if err != nil {
+			return goValue{}, err
+		}
+		var e jx.Encoder
+		e.ByteStr(raw)
+		return goValue{raw: e.Bytes()}, nil
+	}
+	return goValue{rv: rv}, nil
+}
+
+// startDetectingCyclesAfter is a depth of nested Go values, after which
+// cycles are detected, like encoding/json does.
+const startDetectingCyclesAfter = 1000
+
+// valueKey identifies Go value, that may be a part of a cycle.
+type valueKey struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+// enterValue increments depth of validated Go value and, if depth is
+// large enough, reports error if given value is already on the current
+// instance path.
+//
+// Returned key must be passed to leaveValue.
+func (v *validator) enterValue(gv goValue) (valueKey, error) {
+	v.valueDepth++
+	rv := gv.rv
+	if v.valueDepth <= startDetectingCyclesAfter || !rv.IsValid() {
+		return valueKey{}, nil
+	}
+
+	var key valueKey
+	switch rv.Kind() {
+	case reflect.Map:
+		key = valueKey{ptr: rv.Pointer(), typ: rv.Type()}
+	case reflect.Slice:
+		// Different slices of the same array are different values.
+		key = valueKey{ptr: rv.Pointer(), len: rv.Len(), typ: rv.Type()}
+	case reflect.Struct, reflect.Array:
+		// Addressable value is pointed by pointer, slice or map.
+		if !rv.CanAddr() {
+			return valueKey{}, nil
+		}
+		key = valueKey{ptr: rv.Addr().Pointer(), typ: rv.Type()}
+	default:
+		return valueKey{}, nil
+	}
+	if _, ok := v.valueSeen[key]; ok {
+		v.valueDepth--
+		return valueKey{}, &json.UnsupportedValueError{
+			Value: rv,
+			Str:   "encountered a cycle via " + rv.Type().String(),
+		}
+	}
+	if v.valueSeen == nil {
+		v.valueSeen = map[valueKey]struct{}{}
+	}
+	v.valueSeen[key] = struct{}{}
+	return key, nil
+}
+
+// leaveValue decrements depth of validated Go value.
+func (v *validator) leaveValue(key valueKey) {
+	v.valueDepth--
+	if key.typ != nil {
+		delete(v.valueSeen, key)
+	}
+}
+
+// isScalarKind reports whether value of given kind is encoded as JSON
+// boolean or number.
+func isScalarKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// marshalerOf returns value as json.Marshaler or encoding.TextMarshaler,
+// if it implements any of them.
+func marshalerOf(rv reflect.Value) (any, bool) {
+	t := rv.Type()
+	if t.Implements(marshalerType) || t.Implements(textMarshalerType) {
+		return rv.Interface(), true
+	}
+	if rv.Kind() != reflect.Pointer && rv.CanAddr() {
+		pt := reflect.PointerTo(t)
+		if pt.Implements(marshalerType) || pt.Implements(textMarshalerType) {
+			return rv.Addr().Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// encode returns JSON encoding of the value.
+func (gv goValue) encode() ([]byte, error) {
+	switch {
+	case gv.raw != nil:
+		return gv.raw, nil
+	case !gv.rv.IsValid():
+		return []byte("null"), nil
+	default:
+		return json.Marshal(gv.rv.Interface())
+	}
+}
+
+func (s *Schema) validateValue(v *validator, gv goValue) error {
+	if gv.raw != nil {
+		return s.validateRaw(v, gv.raw)
+	}
+	if t := v.trace; t != nil {
+		n := t.enter(v, s)
+		err := s.validateValue1(v, gv)
+		t.leave(n, err)
+		return err
+	}
+	return s.validateValue1(v, gv)
+}
+
+func (s *Schema) validateValue1(v *validator, gv goValue) error {
+	if ref := s.ref; ref != nil && s.refOverride {
+		n := v.keyword.push("$ref")
+		err := ref.validateValue(v, gv)
+		v.keyword.pop(n)
+		return err
+	}
+
+	st := s.enter(v)
+	err := s.validateValueKeywords(v, gv)
+	s.leave(v, st, err)
+	return err
+}
+
+// validateValueKeywords validates value against keywords of the schema.
+func (s *Schema) validateValueKeywords(v *validator, gv goValue) error {
+	if s.never {
+		return v.fail(s, "", false, nil, "false schema does not allow any value")
+	}
+
+	var errs errorList
+	if s.hasInPlace() {
+		var data []byte
+		if len(s.enum) > 0 || s.constant != nil {
+			var err error
+			data, err = gv.encode()
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := s.validateInPlace(v, data, func(sub *Schema) error {
+			return sub.validateValue(v, gv)
+		}); err != nil && v.report(&errs, err) {
+			return errs.err()
+		}
+	}
+
+	var err error
+	switch rv := gv.rv; {
+	case !rv.IsValid():
+		err = s.checkType(v, nullType)
+	case rv.Type() == jsonNumberType:
+		err = s.validateValueNumber(v, rv)
+	default:
+		err = s.validateValueKind(v, gv)
+	}
+	if err != nil {
+		errs.add(err)
+	}
+	return errs.err()
+}
+
+// validateValueKind validates non-null value against keywords of its type.
+func (s *Schema) validateValueKind(v *validator, gv goValue) error {
+	switch rv := gv.rv; rv.Kind() {
+	case reflect.Bool:
+		return s.checkType(v, booleanType)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return s.validateValueNumber(v, rv)
+	case reflect.String:
+		return s.validateValueString(v, []byte(rv.String()))
+	case reflect.Slice:
+		switch {
+		case rv.IsNil():
+			return s.checkType(v, nullType)
+		case isBytes(rv.Type()):
+			return s.validateValueString(v, base64.StdEncoding.AppendEncode(nil, rv.Bytes()))
+		default:
+			return s.validateValueArray(v, rv)
+		}
+	case reflect.Array:
+		return s.validateValueArray(v, rv)
+	case reflect.Map:
+		if rv.IsNil() {
+			return s.checkType(v, nullType)
+		}
+		props, err := mapProps(rv)
+		if err != nil {
+			return err
+		}
+		return s.validateValueObject(v, gv, props)
+	case reflect.Struct:
+		props, err := structProps(rv)
+		if err != nil {
+			return err
+		}
+		return s.validateValueObject(v, gv, props)
+	default:
+		return &json.UnsupportedTypeError{Type: rv.Type()}
+	}
+}
+
+// isBytes reports whether slice of given type is encoded as base64 string.
+func isBytes(t reflect.Type) bool {
+	if t.Elem().Kind() != reflect.Uint8 {
+		return false
+	}
+	pt := reflect.PointerTo(t.Elem())
+	return !pt.Implements(marshalerType) && !pt.Implements(textMarshalerType)
+}
+
+func (s *Schema) validateValueString(v *validator, str []byte) error {
+	var errs errorList
+	if err := s.checkType(v, stringType); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+	if s.hasStringChecks() {
+		if err := s.validateStr(v, str); err != nil {
+			errs.add(err)
+		}
+	}
+	return errs.err()
+}
+
+func (s *Schema) validateValueNumber(v *validator, rv reflect.Value) error {
+	// Format number even if it is not checked, to report values, that
+	// can't be encoded.
+	var num jx.Num
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		num = strconv.AppendInt(nil, rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		num = strconv.AppendUint(nil, rv.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		var err error
+		num, err = appendFloat(nil, rv.Float(), rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+	default:
+		// json.Number.
+		str := rv.String()
+		if str == "" {
+			str = "0"
+		}
+		d := jx.DecodeStr(str)
+		if d.Next() != jx.Number {
+			return errors.Errorf("invalid number literal %q", str)
+		}
+		n, err := d.Num()
+		if err != nil || len(n) != len(str) {
+			return errors.Errorf("invalid number literal %q", str)
+		}
+		num = n
+	}
+	return s.validateNum(v, num)
+}
+
+// appendFloat appends float, formatted like encoding/json does.
+func appendFloat(b []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, &json.UnsupportedValueError{
+			Value: reflect.ValueOf(f),
+			Str:   strconv.FormatFloat(f, 'g', -1, bits),
+		}
+	}
+
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// Clean up e-09 to e-9.
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b, nil
+}
+
+func (s *Schema) validateValueArray(v *validator, rv reflect.Value) error {
+	var errs errorList
+	if err := s.checkType(v, arrayType); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+	if !s.hasArrayChecks() {
+		return errs.err()
+	}
+
+	state := arrayState{eval: v.eval}
+	// Items are different instances.
+	v.eval = nil
+	defer func() {
+		v.eval = state.eval
+	}()
+	for i := range rv.Len() {
+		item, err := newGoValue(rv.Index(i), false)
+		if err != nil {
+			return err
+		}
+
+		key, err := v.enterValue(item)
+		if err != nil {
+			return err
+		}
+		n := v.instance.pushIndex(i)
+		err = s.validateValueItem(v, i, item, &state)
+		v.instance.pop(n)
+		v.leaveValue(key)
+
+		if err != nil && v.report(&errs, err) {
+			return errs.err()
+		}
+	}
+
+	if err := s.validateArrayEnd(v, rv.Len(), &state); err != nil {
+		errs.add(err)
+	}
+	return errs.err()
+}
+
+func (s *Schema) validateValueItem(v *validator, idx int, item goValue, state *arrayState) error {
+	if s.uniqueItems {
+		raw, err := item.encode()
+		if err != nil {
+			return err
+		}
+		state.items = append(state.items, raw)
+	}
+	if s.contains != nil {
+		s.validateContains(v, idx, state, func(sub *Schema) error {
+			return sub.validateValue(v, item)
+		})
+	}
+
+	n := v.keyword.len()
+	defer v.keyword.pop(n)
+
+	var errs errorList
+	sch, err := s.itemSchema(v, idx, state)
+	if err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+	if sch != nil {
+		if err := sch.validateValue(v, item); err != nil {
+			errs.add(err)
+		}
+	}
+	return errs.err()
+}
+
+// goProp is a property of Go map or struct.
+type goProp struct {
+	key []byte
+	val goValue
+}
+
+func (s *Schema) validateValueObject(v *validator, gv goValue, props []goProp) error {
+	var errs errorList
+	if err := s.checkType(v, objectType); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+	if !s.hasObjectChecks() {
+		return errs.err()
+	}
+
+	var (
+		// required maps required property name to the name of property
+		// that requires it, or to an empty string, if it is required by
+		// "required" keyword.
+		required map[string]string
+		// Stack-allocated slice.
+		dependent = make([]dependentSchema, 0, 8)
+	)
+	if len(s.required) > 0 || len(s.dependentRequired) > 0 {
+		required = make(map[string]string, len(s.required))
+		for k := range s.required {
+			required[k] = ""
+		}
+	}
+	if s.hasDependent() {
+		for _, p := range props {
+			dependent = s.addDependent(required, dependent, p.key)
+		}
+	}
+	if err := s.validateDependentSchemas(v, dependent, func(sub *Schema) error {
+		return sub.validateValue(v, gv)
+	}); err != nil && v.report(&errs, err) {
+		return errs.err()
+	}
+
+	eval := v.eval
+	// Property values are different instances.
+	v.eval = nil
+	defer func() {
+		v.eval = eval
+	}()
+
+	for _, p := range props {
+		delete(required, string(p.key))
+
+		if err := s.validatePropertyName(v, p.key); err != nil && v.report(&errs, err) {
+			return errs.err()
+		}
+
+		unevaluated := s.markProperty(eval, p.key)
+		apply := func(sub *Schema) error {
+			return sub.validateValue(v, p.val)
+		}
+
+		key, err := v.enterValue(p.val)
+		if err != nil {
+			return err
+		}
+		n := v.instance.pushBytes(p.key)
+		if unevaluated {
+			err = s.validateUnevaluatedProperty(v, p.key, apply)
+		} else {
+			err = s.validateProperty(v, p.key, apply)
+		}
+		v.instance.pop(n)
+		v.leaveValue(key)
+
+		if err != nil && v.report(&errs, err) {
+			return errs.err()
+		}
+	}
+
+	if err := s.validateObjectEnd(v, len(props), required); err != nil {
+		errs.add(err)
+	}
+	return errs.err()
+}
+
+// mapProps returns properties of given map, sorted by key.
+func mapProps(rv reflect.Value) ([]goProp, error) {
+	props := make([]goProp, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		key, err := mapKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		val, err := newGoValue(iter.Value(), false)
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, goProp{key: []byte(key), val: val})
+	}
+	slices.SortFunc(props, func(a, b goProp) int {
+		return strings.Compare(string(a.key), string(b.key))
+	})
+	return props, nil
+}
+
+// mapKey returns map key, encoded like encoding/json does.
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", nil
+		}
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	default:
+		return "", &json.UnsupportedTypeError{Type: k.Type()}
+	}
+}
+
+// structProps returns encoded fields of given struct, in order of
+// declaration.
+func structProps(rv reflect.Value) ([]goProp, error) {
+	fields := cachedFields(rv.Type())
+	props := make([]goProp, 0, len(fields))
+	for i := range fields {
+		f := &fields[i]
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok || f.omit(fv) {
+			continue
+		}
+		val, err := newGoValue(fv, f.quoted)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %s", f.name)
+		}
+		props = append(props, goProp{key: []byte(f.name), val: val})
+	}
+	return props, nil
+}
+
+// fieldByIndex returns nested field by index.
+//
+// If embedded pointer is nil, fieldByIndex returns false.
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+// structField is a struct field, encoded by encoding/json.
+type structField struct {
+	name  string
+	index []int
+	typ   reflect.Type
+	// tagged is set if name is set by tag.
+	tagged    bool
+	omitEmpty bool
+	omitZero  bool
+	quoted    bool
+}
+
+type zeroer interface {
+	IsZero() bool
+}
+
+var zeroerType = reflect.TypeFor[zeroer]()
+
+// omit reports whether field with given value is omitted.
+func (f *structField) omit(fv reflect.Value) bool {
+	if f.omitEmpty && isEmptyGoValue(fv) {
+		return true
+	}
+	if !f.omitZero {
+		return false
+	}
+	switch t := fv.Type(); {
+	case t.Kind() == reflect.Pointer && t.Implements(zeroerType):
+		return fv.IsNil() || fv.Interface().(zeroer).IsZero()
+	case t.Implements(zeroerType):
+		return fv.Interface().(zeroer).IsZero()
+	case reflect.PointerTo(t).Implements(zeroerType):
+		if !fv.CanAddr() {
+			c := reflect.New(t).Elem()
+			c.Set(fv)
+			fv = c
+		}
+		return fv.Addr().Interface().(zeroer).IsZero()
+	default:
+		return fv.IsZero()
+	}
+}
+
+// isEmptyGoValue reports whether value is empty, according to
+// "omitempty" option.
+func isEmptyGoValue(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return fv.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return fv.IsZero()
+	default:
+		return false
+	}
+}
+
+// fieldCache maps struct types to lists of encoded fields.
+var fieldCache sync.Map
+
+func cachedFields(t reflect.Type) []structField {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]structField)
+	}
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return f.([]structField)
+}
+
+// typeFields returns fields of given struct type, encoded by
+// encoding/json, including fields of embedded structs.
+//
+// Like encoding/json, field with the shallowest depth wins; at the same
+// depth, tagged field wins, other conflicting fields are dropped.
+func typeFields(t reflect.Type) []structField {
+	var (
+		current []structField
+		next    = []structField{{typ: t}}
+		// count and nextCount are number of times given embedded
+		// type is found at the current and the next depth.
+		count, nextCount map[reflect.Type]int
+		visited          = map[reflect.Type]bool{}
+		fields           []structField
+	)
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, f := range current {
+			if visited[f.typ] {
+				continue
+			}
+			visited[f.typ] = true
+
+			for i := range f.typ.NumField() {
+				sf := f.typ.Field(i)
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				if !isValidTag(name) {
+					name = ""
+				}
+				index := append(slices.Clip(f.index), i)
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+
+				if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+					field := structField{
+						name:      name,
+						index:     index,
+						typ:       ft,
+						tagged:    name != "",
+						omitEmpty: hasTagOption(opts, "omitempty"),
+						omitZero:  hasTagOption(opts, "omitzero"),
+					}
+					if field.name == "" {
+						field.name = sf.Name
+					}
+					if hasTagOption(opts, "string") {
+						field.quoted = isScalarKind(ft.Kind()) || ft.Kind() == reflect.String
+					}
+					fields = append(fields, field)
+					if count[f.typ] > 1 {
+						// Embedded more than once at the same depth:
+						// add a duplicate to annihilate the field.
+						fields = append(fields, field)
+					}
+					continue
+				}
+
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, structField{name: ft.Name(), index: index, typ: ft})
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(fields, func(a, b structField) int {
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		if c := len(a.index) - len(b.index); c != 0 {
+			return c
+		}
+		if a.tagged != b.tagged {
+			if a.tagged {
+				return -1
+			}
+			return 1
+		}
+		return slices.Compare(a.index, b.index)
+	})
+
+	// Keep the dominant field of each name.
+	out := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		group := fields[i:j]
+		if len(group) == 1 ||
+			len(group[0].index) != len(group[1].index) ||
+			group[0].tagged != group[1].tagged {
+			out = append(out, group[0])
+		}
+		i = j
+	}
+
+	slices.SortFunc(out, func(a, b structField) int {
+		return slices.Compare(a.index, b.index)
+	})
+	return out
+}
+
+// hasTagOption reports whether comma-separated list of tag options
+// contains given option.
+func hasTagOption(opts, option string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidTag reports whether given name is valid JSON name in tag,
+// like encoding/json does.
+func isValidTag(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// Backslash and quote chars are reserved, but otherwise any
+			// punctuation chars are allowed in a tag name.
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,345 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// decodeValue decodes JSON to Go value, keeping numbers as json.Number.
+func decodeValue(data []byte) (val any, err error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	err = d.Decode(&val)
+	return val, err
+}
+
+type valueBase struct {
+	ID      int    `json:"id"`
+	Comment string `json:"comment,omitempty"`
+}
+
+type valueMeta struct {
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type valueConfig struct {
+	valueBase
+	*valueMeta
+	Name     string            `json:"name"`
+	Port     uint16            `json:"port"`
+	Ratio    float64           `json:"ratio"`
+	Count    int64             `json:"count,string"`
+	Enabled  *bool             `json:"enabled,omitempty"`
+	Tags     []string          `json:"tags"`
+	Payload  []byte            `json:"payload,omitempty"`
+	Addr     netip.Addr        `json:"addr,omitzero"`
+	Timeout  time.Time         `json:"timeout,omitzero"`
+	Limits   map[int]float32   `json:"limits,omitempty"`
+	Extra    json.RawMessage   `json:"extra,omitempty"`
+	Any      any               `json:"any,omitempty"`
+	Nested   []valueBase       `json:"nested,omitempty"`
+	Ignored  string            `json:"-"`
+	Untagged string            //nolint:tagliatelle
+	Numbers  map[string]any    `json:"numbers,omitempty"`
+	Raw      map[string][]byte `json:"raw,omitempty"`
+	private  string
+}
+
+const valueSchema = `{
+	"type": "object",
+	"required": ["id", "name", "port", "ratio", "count", "tags"],
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"comment": {"type": "string", "maxLength": 10},
+		"labels": {
+			"type": "object",
+			"additionalProperties": {"type": "string", "pattern": "^[a-z]+$"}
+		},
+		"name": {"type": "string", "minLength": 1},
+		"port": {"type": "integer", "maximum": 1024},
+		"ratio": {"type": "number", "exclusiveMaximum": 1},
+		"count": {"type": "string", "pattern": "^[0-9]+$"},
+		"enabled": {"const": true},
+		"tags": {"type": "array", "uniqueItems": true, "items": {"enum": ["a", "b", "c"]}},
+		"payload": {"type": "string", "contentEncoding": "base64", "maxLength": 8},
+		"addr": {"type": "string", "format": "ipv4"},
+		"timeout": {"type": "string", "format": "date-time"},
+		"limits": {"propertyNames": {"pattern": "^[0-9]+$"}, "additionalProperties": {"type": "number", "multipleOf": 0.5}},
+		"extra": {"type": "object", "maxProperties": 1},
+		"any": {"type": ["null", "integer"]},
+		"nested": {"type": "array", "contains": {"properties": {"id": {"const": 2}}}, "maxItems": 2},
+		"Untagged": {"type": "string"},
+		"numbers": {"additionalProperties": {"type": "integer", "maximum": 1e3}},
+		"raw": {"additionalProperties": {"type": "string"}}
+	},
+	"dependencies": {"comment": ["labels"]},
+	"additionalProperties": false
+}`
+
+func TestSchema_ValidateValue(t *testing.T) {
+	sch, err := Parse([]byte(valueSchema))
+	require.NoError(t, err)
+
+	valid := func() valueConfig {
+		return valueConfig{
+			valueBase: valueBase{ID: 1},
+			Name:      "foo",
+			Port:      80,
+			Ratio:     0.5,
+			Count:     10,
+			Tags:      []string{"a", "b"},
+		}
+	}
+	enabled, disabled := true, false
+
+	for i, tt := range []struct {
+		name  string
+		value func(c *valueConfig)
+	}{
+		{"Valid", func(c *valueConfig) {}},
+		{"ValidAll", func(c *valueConfig) {
+			c.Comment = "comment"
+			c.valueMeta = &valueMeta{Labels: map[string]string{"app": "foo"}}
+			c.Enabled = &enabled
+			c.Payload = []byte("foo")
+			c.Addr = netip.MustParseAddr("127.0.0.1")
+			c.Timeout = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			c.Limits = map[int]float32{1: 1.5, 10: 2}
+			c.Extra = json.RawMessage(`{"a": 1}`)
+			c.Any = json.Number("10")
+			c.Nested = []valueBase{{ID: 1}, {ID: 2}}
+			c.Numbers = map[string]any{"a": 1, "b": uint8(2), "c": 3.0, "d": json.Number("1e2")}
+			c.Raw = map[string][]byte{"a": nil}
+		}},
+		{"BadID", func(c *valueConfig) { c.ID = 0 }},
+		{"BadComment", func(c *valueConfig) { c.Comment = "long comment" }},
+		{"MissingDependency", func(c *valueConfig) { c.Comment = "comment" }},
+		{"BadLabel", func(c *valueConfig) {
+			c.valueMeta = &valueMeta{Labels: map[string]string{"app": "Foo"}}
+		}},
+		{"EmptyName", func(c *valueConfig) { c.Name = "" }},
+		{"BigPort", func(c *valueConfig) { c.Port = 8080 }},
+		{"BigRatio", func(c *valueConfig) { c.Ratio = 1 }},
+		{"NegativeCount", func(c *valueConfig) { c.Count = -1 }},
+		{"Disabled", func(c *valueConfig) { c.Enabled = &disabled }},
+		{"NilTags", func(c *valueConfig) { c.Tags = nil }},
+		{"DuplicateTags", func(c *valueConfig) { c.Tags = []string{"a", "a"} }},
+		{"UnknownTag", func(c *valueConfig) { c.Tags = []string{"d"} }},
+		{"LongPayload", func(c *valueConfig) { c.Payload = []byte("foobarbaz") }},
+		{"IPv6", func(c *valueConfig) { c.Addr = netip.MustParseAddr("::1") }},
+		{"BadLimitKey", func(c *valueConfig) { c.Limits = map[int]float32{-1: 1} }},
+		{"BadLimit", func(c *valueConfig) { c.Limits = map[int]float32{1: 0.1} }},
+		{"BigExtra", func(c *valueConfig) { c.Extra = json.RawMessage(`{"a": 1, "b": 2}`) }},
+		{"FloatAny", func(c *valueConfig) { c.Any = 1.5 }},
+		{"IntegralFloatAny", func(c *valueConfig) { c.Any = 2.0 }},
+		{"NoContains", func(c *valueConfig) { c.Nested = []valueBase{{ID: 1}} }},
+		{"TooManyNested", func(c *valueConfig) { c.Nested = []valueBase{{ID: 2}, {ID: 2}, {ID: 2}} }},
+		{"Untagged", func(c *valueConfig) { c.Untagged = "foo" }},
+		{"BigNumber", func(c *valueConfig) { c.Numbers = map[string]any{"a": json.Number("1e4")} }},
+		{"FractionalNumber", func(c *valueConfig) { c.Numbers = map[string]any{"a": float32(0.1)} }},
+		{"NestedMap", func(c *valueConfig) { c.Numbers = map[string]any{"a": map[string]any{}} }},
+		{"RawBytes", func(c *valueConfig) { c.Raw = map[string][]byte{"a": []byte("foo")} }},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			a := require.New(t)
+
+			val := valid()
+			tt.value(&val)
+
+			data, err := json.Marshal(val)
+			a.NoError(err)
+			expected := sch.Validate(data)
+
+			for _, v := range []any{val, &val} {
+				err := sch.ValidateValue(v)
+				if expected == nil {
+					a.NoError(err, "%d: %s", i, data)
+					continue
+				}
+				a.Error(err, "%d: %s", i, data)
+
+				var expectedErr, actualErr *ValidationError
+				a.ErrorAs(expected, &expectedErr)
+				a.ErrorAs(err, &actualErr)
+				a.Equal(expectedErr.InstanceLocation, actualErr.InstanceLocation)
+				a.Equal(expectedErr.KeywordLocation, actualErr.KeywordLocation)
+				a.Equal(expectedErr.Message, actualErr.Message)
+			}
+		})
+	}
+}
+
+func TestSchema_ValidateValueScalar(t *testing.T) {
+	sch, err := Parse([]byte(`{"type": ["integer", "null"], "maximum": 10}`))
+	require.NoError(t, err)
+
+	for i, tt := range []struct {
+		value any
+		valid bool
+	}{
+		{nil, true},
+		{(*int)(nil), true},
+		{[]int(nil), true},
+		{map[string]int(nil), true},
+		{10, true},
+		{int8(-10), true},
+		{uint64(10), true},
+		{10.0, true},
+		{json.Number("10"), true},
+		{json.Number("-1e1"), false},
+
+		{11, false},
+		{10.5, false},
+		{json.Number("11"), false},
+		{"10", false},
+		{true, false},
+		{[]int{}, false},
+		{struct{}{}, false},
+	} {
+		err := sch.ValidateValue(tt.value)
+		if tt.valid {
+			require.NoError(t, err, "test %d: %#v", i, tt.value)
+		} else {
+			require.Error(t, err, "test %d: %#v", i, tt.value)
+		}
+	}
+}
+
+func TestSchema_ValidateValueUnsupported(t *testing.T) {
+	sch, err := Parse([]byte(`{}`))
+	require.NoError(t, err)
+
+	for i, value := range []any{
+		math.NaN(),
+		math.Inf(1),
+		json.Number("1.0.0"),
+		make(chan int),
+		func() {},
+	} {
+		_, marshalErr := json.Marshal(value)
+		require.Error(t, marshalErr, "test %d: %#v", i, value)
+		require.Error(t, sch.ValidateValue(value), "test %d: %#v", i, value)
+	}
+}
+
+func TestSchema_ValidateValueCycle(t *testing.T) {
+	sch, err := Parse([]byte(`{
+		"type": ["object", "array", "null"],
+		"properties": {"next": {"$ref": "#"}},
+		"additionalProperties": {"$ref": "#"},
+		"items": {"$ref": "#"}
+	}`))
+	require.NoError(t, err)
+
+	type node struct {
+		Next *node `json:"next"`
+	}
+	ptr := &node{}
+	ptr.Next = ptr
+
+	m := map[string]any{}
+	m["m"] = m
+
+	sl := make([]any, 1)
+	sl[0] = sl
+
+	for i, value := range []any{ptr, m, sl} {
+		_, marshalErr := json.Marshal(value)
+		require.Error(t, marshalErr, "test %d", i)
+
+		err := sch.ValidateValue(value)
+		var unsupportedErr *json.UnsupportedValueError
+		require.ErrorAs(t, err, &unsupportedErr, "test %d", i)
+	}
+
+	// Shared, but not cyclic values are valid.
+	shared := &node{}
+	require.NoError(t, sch.ValidateValue([]*node{shared, shared}))
+}
+
+func TestSchema_ValidateValueFields(t *testing.T) {
+	type inner struct {
+		A string
+		B int
+	}
+	type conflict struct {
+		B int
+	}
+	type value struct {
+		inner
+		conflict
+		C int `json:"A"`
+	}
+
+	// Promoted "A" is shadowed by tagged field, conflicting "B" fields
+	// are dropped.
+	sch, err := Parse([]byte(`{
+		"properties": {"A": {"type": "integer"}},
+		"required": ["A"],
+		"not": {"required": ["B"]}
+	}`))
+	require.NoError(t, err)
+
+	v := value{inner: inner{A: "a", B: 1}, conflict: conflict{B: 2}, C: 3}
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"A": 3}`, string(data))
+	require.NoError(t, sch.Validate(data))
+	require.NoError(t, sch.ValidateValue(v))
+}
+
+func TestBenchSuiteValue(t *testing.T) {
+	for _, s := range collectBench(t) {
+		s := s
+		t.Run(s.Name, func(t *testing.T) {
+			if s.Skip {
+				t.Skip("Unsupported yet")
+			}
+
+			for _, data := range s.Data {
+				data := data
+				t.Run(data.Name, func(t *testing.T) {
+					val, err := decodeValue(data.Data)
+					require.NoError(t, err)
+					require.NoError(t, s.Schema.ValidateValue(val))
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkValidateValue(b *testing.B) {
+	for _, s := range collectBench(b) {
+		s := s
+		b.Run(s.Name, func(b *testing.B) {
+			if s.Skip {
+				b.Skip("Unsupported yet")
+			}
+
+			for _, data := range s.Data {
+				data := data
+				b.Run(data.Name, func(b *testing.B) {
+					val, err := decodeValue(data.Data)
+					if err != nil {
+						b.Fatal(err)
+					}
+					b.ReportAllocs()
+					b.ResetTimer()
+
+					for i := 0; i < b.N; i++ {
+						if err := s.Schema.ValidateValue(val); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		})
+	}
+}